@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"lab2/sequence"
+	"log"
+	"strings"
+	"time"
+)
+
+// kinds of all-vs-all matrices
+const (
+	matScore    = "score"
+	matIdentity = "identity"
+	matP        = "p"
+	matPoisson  = "poisson"
+	matKimura   = "kimura"
+
+	formatTSV    = "tsv"
+	formatPhylip = "phylip"
+)
+
+// isNucleotideType reports if selected table scores nucleotides
+func isNucleotideType() bool {
+	t := strings.ToUpper(tableType)
+	return matrixFile == "" && (tableType == useDNA || t == "NUC.4.4" || t == "DNAFULL")
+}
+
+// readAllSeqs reads sequences of all files, gaps are removed
+func readAllSeqs(files []string) []*AminoSequence {
+	seqs := make([]*AminoSequence, 0)
+	for _, file := range files {
+		fileSeqs, err := readSeqsFromFile(file)
+		if err != nil {
+			fatal(err.Error())
+		}
+		for _, seq := range fileSeqs {
+			seqs = append(seqs, seq.Ungapped())
+		}
+	}
+	return seqs
+}
+
+// seqNames returns IDs of seqs, sequences without ID are named by their number
+func seqNames(seqs []*AminoSequence) []string {
+	names := make([]string, len(seqs))
+	for i, seq := range seqs {
+		names[i] = seq.ID
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("seq%d", i+1)
+		}
+	}
+	return names
+}
+
+// allVsAll alligns every pair of seqs, stats are calculated only if withStats
+func allVsAll(seqs []*AminoSequence, withStats bool) *sequence.PairMatrix {
+	allg := newAlligner()
+	values := make([]string, len(seqs))
+	for i, seq := range seqs {
+		values[i] = seq.Value
+	}
+	pairAlg := func(i, j int) sequence.Alligner {
+		return pairAlligner(allg, seqs[i], seqs[j])
+	}
+	res, err := sequence.AllVsAll(pairAlg, values, newOptions(), withStats)
+	if err != nil {
+		fatal("alligning %s", err)
+	}
+	return res
+}
+
+// distanceMatrix converts all-vs-all results to matrix of kind
+func distanceMatrix(pm *sequence.PairMatrix, kind string) [][]float64 {
+	if kind == matScore {
+		return pm.Scores
+	}
+	res := make([][]float64, len(pm.Stats))
+	for i := range pm.Stats {
+		res[i] = make([]float64, len(pm.Stats[i]))
+		for j, s := range pm.Stats[i] {
+			switch kind {
+			case matIdentity:
+				res[i][j] = s.Identity()
+			case matP:
+				res[i][j] = s.PDistance()
+			case matPoisson:
+				res[i][j] = s.PoissonDistance()
+			case matKimura:
+				if isNucleotideType() {
+					res[i][j] = s.Kimura2PDistance()
+				} else {
+					res[i][j] = s.KimuraProteinDistance()
+				}
+			}
+		}
+	}
+	return res
+}
+
+func formatMatrix(names []string, m [][]float64, format string) string {
+	bld := strings.Builder{}
+	switch format {
+	case formatTSV:
+		for _, name := range names {
+			bld.WriteString("\t" + name)
+		}
+		bld.WriteByte('\n')
+		for i, row := range m {
+			bld.WriteString(names[i])
+			for _, v := range row {
+				bld.WriteString(fmt.Sprintf("\t%g", v))
+			}
+			bld.WriteByte('\n')
+		}
+	case formatPhylip:
+		bld.WriteString(fmt.Sprintf("%d\n", len(names)))
+		// names of strict phylip are exactly 10 characters
+		for i, row := range m {
+			bld.WriteString(fmt.Sprintf("%-10.10s", names[i]))
+			for _, v := range row {
+				bld.WriteString(fmt.Sprintf(" %.6f", v))
+			}
+			bld.WriteByte('\n')
+		}
+	}
+	return bld.String()
+}
+
+func runAll(files []string) {
+	switch outMatrix {
+	case matScore, matIdentity, matP, matPoisson, matKimura:
+	default:
+		fatal("unknown matrix kind %q", outMatrix)
+	}
+	if outFormat != formatTSV && outFormat != formatPhylip {
+		fatal("unknown format %q", outFormat)
+	}
+	seqs := readAllSeqs(files)
+	if len(seqs) < 2 {
+		fatal("bad amount of sequeces %d", len(seqs))
+	}
+
+	t := time.Now()
+	pm := allVsAll(seqs, outMatrix != matScore)
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+	out := formatMatrix(seqNames(seqs), distanceMatrix(pm, outMatrix), outFormat)
+	printOut(func(bool) string {
+		return out
+	})
+}
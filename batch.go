@@ -0,0 +1,173 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"lab2/sequence"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dbHit is a database record alligned with query
+type dbHit struct {
+	idx   int
+	seq   *AminoSequence
+	score float64
+}
+
+// better reports if h should be ranked above o, ties are kept in database order
+func (h dbHit) better(o dbHit) bool {
+	if h.score != o.score {
+		return h.score > o.score
+	}
+	return h.idx < o.idx
+}
+
+// hitHeap keeps the worst hit on top so it can be dropped when a better one comes
+type hitHeap []dbHit
+
+func (h hitHeap) Len() int            { return len(h) }
+func (h hitHeap) Less(i, j int) bool  { return h[j].better(h[i]) }
+func (h hitHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *hitHeap) Push(x interface{}) { *h = append(*h, x.(dbHit)) }
+func (h *hitHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// topHits collects the best n hits, all hits if n is 0
+type topHits struct {
+	n    int
+	hits hitHeap
+}
+
+func (t *topHits) add(hit dbHit) {
+	if t.n == 0 || len(t.hits) < t.n {
+		heap.Push(&t.hits, hit)
+		return
+	}
+	if hit.better(t.hits[0]) {
+		t.hits[0] = hit
+		heap.Fix(&t.hits, 0)
+	}
+}
+
+// sorted returns hits from the best one
+func (t *topHits) sorted() []dbHit {
+	res := append([]dbHit(nil), t.hits...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].better(res[j])
+	})
+	return res
+}
+
+// scoreDB scores query against every record of database file on amThreads workers.
+// Records are streamed, only the best top hits are kept in memory.
+// Amount of records and residues of database are returned with hits.
+func scoreDB(allg sequence.Alligner, opts sequence.Options, query *AminoSequence, dbFile string, top int) ([]dbHit, int, int) {
+	jobs := make(chan dbHit, 2*amThreads)
+	results := make(chan dbHit, 2*amThreads)
+	scoreOpts := opts
+	scoreOpts.Threads = 1
+
+	wg := sync.WaitGroup{}
+	wg.Add(amThreads)
+	for i := 0; i < amThreads; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				seq := job.seq.Ungapped()
+				score, err := sequence.Score(pairAlligner(allg, query, seq), query.Value, seq.Value, scoreOpts)
+				if err != nil {
+					log.Printf("skipping %s: record %d (%s): %s", dbFile, job.idx+1, seq.ID, err)
+					continue
+				}
+				job.seq, job.score = seq, score
+				results <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var readErr error
+	residues := 0
+	go func() {
+		idx := 0
+		readErr = forEachSeq(dbFile, func(seq *AminoSequence) error {
+			jobs <- dbHit{idx: idx, seq: seq}
+			idx++
+			residues += len(seq.Value)
+			return nil
+		})
+		close(jobs)
+	}()
+
+	hits := topHits{n: top}
+	total := 0
+	for res := range results {
+		hits.add(res)
+		total++
+	}
+	if readErr != nil {
+		fatal(readErr.Error())
+	}
+	return hits.sorted(), total, residues
+}
+
+func runDB(files []string) {
+	if len(files) != 2 {
+		fatal("db mode needs query and database files, got %d files", len(files))
+	}
+	if top < 0 {
+		fatal("-top must not be negative")
+	}
+	if amThreads <= 0 {
+		amThreads = 1
+	}
+	queries, err := readSeqsFromFile(files[0])
+	if err != nil {
+		fatal(err.Error())
+	}
+	if len(queries) != 1 {
+		fatal("query file should have 1 sequence, got %d", len(queries))
+	}
+	query := queries[0].Ungapped()
+	allg := queryAlligner(newAlligner(), query)
+	opts := withGaps(newOptions(), query, nil)
+
+	t := time.Now()
+	hits, total, residues := scoreDB(allg, opts, query, files[1], top)
+	initStats(residues)
+	res := make([]*sequence.Allignment, len(hits))
+	for i, hit := range hits {
+		res[i], err = sequence.AllignWithOptions(pairAlligner(allg, query, hit.seq), query.Value, hit.seq.Value, opts)
+		if err != nil {
+			fatal("alligning %s: %s", hit.seq.ID, err)
+		}
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+
+	printOut(func(withColor bool) string {
+		bld := strings.Builder{}
+		bld.WriteString(fmt.Sprintf("query: %s\n", query.Header))
+		bld.WriteString(fmt.Sprintf("hits: %d of %d\n", len(hits), total))
+		for i, hit := range hits {
+			bld.WriteString(fmt.Sprintf("%d\t%g%s\t%s\t%s\n", i+1, hit.score, statsColumns(hit.score, len(query.Value)), hit.seq.ID, hit.seq.Description))
+		}
+		for i, hit := range hits {
+			bld.WriteString(fmt.Sprintf("\nhit %d: %s\n", i+1, hit.seq.Header))
+			bld.WriteString(formatRes(pairAlligner(allg, query, hit.seq), res[i], query, hit.seq, withColor))
+		}
+		return bld.String()
+	})
+}
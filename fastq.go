@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadQuality is returned when FASTQ quality line does not match the sequence
+var ErrBadQuality = errors.New("fastq parser: bad quality")
+
+// phredOffset is the offset of Phred quality values in Sanger/Illumina 1.8+ FASTQ
+const phredOffset = 33
+
+// SeqParser reads sequences one by one
+type SeqParser interface {
+	// Next returns io.EOF if all sequences were read
+	Next() (*AminoSequence, error)
+}
+
+// FastqParser parses FASTQ records, sequence may span several lines
+type FastqParser struct {
+	reader       *bufio.Reader
+	headerParser HeaderParser
+}
+
+// NewFastqParser returns new FastqParser, header style is detected by AutoHeaderParser
+func NewFastqParser(r io.Reader) *FastqParser {
+	return &FastqParser{
+		reader:       bufio.NewReader(r),
+		headerParser: AutoHeaderParser,
+	}
+}
+
+// WithHeaderParser sets parser of header lines
+func (p *FastqParser) WithHeaderParser(hp HeaderParser) *FastqParser {
+	p.headerParser = hp
+	return p
+}
+
+func (p *FastqParser) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// Next gets next record from reader.
+// Returns io.EOF if all records were read.
+func (p *FastqParser) Next() (*AminoSequence, error) {
+	header, err := p.readLine()
+	for err == nil && header == "" {
+		header, err = p.readLine()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header[0] != '@' {
+		return nil, errors.WithMessagef(ErrBadHeader, "header %q", header)
+	}
+	id, descr, err := p.headerParser.ParseHeader(header[1:])
+	if err != nil {
+		return nil, errors.WithMessagef(err, "header %q", header)
+	}
+
+	rb := &residueBuilder{}
+	for {
+		line, err := p.readLine()
+		if err == io.EOF {
+			return nil, errors.Wrapf(ErrBadQuality, "no quality in %s", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "+") {
+			break
+		}
+		for i := 0; i < len(line); i++ {
+			if !rb.add(line[i]) {
+				return nil, errors.Wrapf(ErrUnknownSymbol, "%q in %s", line[i], id)
+			}
+		}
+	}
+
+	quality := make([]byte, 0, rb.value.Len())
+	for len(quality) < rb.value.Len() {
+		line, err := p.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(line); i++ {
+			if line[i] < phredOffset || line[i] > '~' {
+				return nil, errors.Wrapf(ErrBadQuality, "symbol %q in %s", line[i], id)
+			}
+			quality = append(quality, line[i]-phredOffset)
+		}
+	}
+	if len(quality) != rb.value.Len() {
+		return nil, errors.Wrapf(ErrBadQuality, "%d quality values for %d residues in %s", len(quality), rb.value.Len(), id)
+	}
+
+	return &AminoSequence{
+		ID:          id,
+		Description: descr,
+		Header:      header[1:],
+		Value:       rb.value.String(),
+		Mask:        rb.mask,
+		Quality:     quality,
+	}, nil
+}
+
+// NewSeqParser detects format of r by the first symbol: '>' for FASTA, '@' for FASTQ
+func NewSeqParser(r io.Reader, hp HeaderParser) (SeqParser, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, errors.New("empty input")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		if err := br.UnreadByte(); err != nil {
+			return nil, err
+		}
+		switch b {
+		case '>':
+			return NewFastaParser(br).WithHeaderParser(hp), nil
+		case '@':
+			return NewFastqParser(br).WithHeaderParser(hp), nil
+		}
+		return nil, errors.Errorf("unknown format, first symbol is %q", b)
+	}
+}
@@ -3,12 +3,29 @@ package main
 import (
 	"flag"
 	"fmt"
+	"lab2/sequence"
 	"os"
+	"strings"
 )
 
 func init() {
-	flag.StringVar(&tableType, "type", useDefault, "table type, one of Blosum64, DNA, Default")
-	flag.StringVar(&tableType, "t", useDefault, "table type, one of Blosum64, DNA, Default")
+	flag.StringVar(&runMode, "mode", modePair, "pair - allign two sequences, db - allign query (file) against every record of database (file2), all - all-vs-all matrix of sequences of all files, tree - Newick tree of sequences of all files, msa - multiple allignment of sequences of all files, index - k-mer index of database files, search - query (file) against index (file2), shuffle - Z-score and p-value of allignment of two sequences by shuffles of the second one")
+	flag.IntVar(&top, "top", 10, "amount of best hits reported in db and search modes, 0 for all")
+	flag.StringVar(&outMatrix, "out-matrix", matScore, "matrix of all mode: score, identity (percent), p, poisson or kimura distance")
+	flag.StringVar(&outFormat, "format", formatTSV, "format of matrix: tsv or phylip, format of msa: clustal, fasta or stockholm")
+	flag.StringVar(&treeKind, "tree", treeUPGMA, "tree method: upgma or nj, also guide tree of msa")
+	flag.StringVar(&tableType, "type", useDefault, "table type: Default, DNA or name of built-in matrix (BLOSUM45, BLOSUM50, BLOSUM62, BLOSUM80, BLOSUM90, PAM30, PAM70, PAM250, NUC.4.4), other matrices are loaded by -matrix-file")
+	flag.StringVar(&tableType, "t", useDefault, "table type: Default, DNA or name of built-in matrix (BLOSUM45, BLOSUM50, BLOSUM62, BLOSUM80, BLOSUM90, PAM30, PAM70, PAM250, NUC.4.4), other matrices are loaded by -matrix-file")
+	flag.StringVar(&matrixFile, "matrix-file", "", "substitution matrix file in NCBI format, overrides -type")
+	flag.StringVar(&pssmFile, "pssm", "", "ASCII PSSM (PSI-BLAST) of the first sequence, pair, db, search and shuffle modes only")
+	flag.StringVar(&gapsFile1, "gaps1", "", "position-specific gap penalties of the first sequence, pair and db modes only")
+	flag.StringVar(&gapsFile2, "gaps2", "", "position-specific gap penalties of the second sequence, pair mode only")
+	flag.Float64Var(&dnaMatch, "match", 5, "match score for -type DNA")
+	flag.Float64Var(&transition, "transition", -4, "transition (A-G, C-T) mismatch score for -type DNA")
+	flag.Float64Var(&transversion, "transversion", -4, "transversion mismatch score for -type DNA")
+	flag.Float64Var(&maskWeight, "mask-weight", 1, "multiplier of scores of soft-masked (lower case) residues")
+	flag.StringVar(&headerStyle, "header", "auto", "FASTA header style: auto, plain, ncbi or uniprot")
+	flag.BoolVar(&useQuality, "quality", false, "lower mismatch penalty at low quality bases of FASTQ input")
 	flag.StringVar(&outFile, "out", "", "output file")
 	flag.StringVar(&outFile, "o", "", "output file")
 	flag.Float64Var(&gap, "gap", -2, "gap value")
@@ -23,6 +40,24 @@ func init() {
 	flag.BoolVar(&logTime, "log-time", false, "print time of processing in log")
 	flag.IntVar(&amThreads, "threads", 8, "amount of threads for computing, for optimal speed use available amount of cpu")
 	flag.BoolVar(&memOpt, "mem-opt", false, "run with memory usage optimized algorithm. it is slower but uses far less memory")
+	flag.BoolVar(&local, "local", false, "local alignment (Smith-Waterman), reports aligned regions of both sequences")
+	flag.IntVar(&kBest, "k-best", 1, "amount of best non-overlapping local allignments (Waterman-Eggert), pair mode with -local only")
+	flag.StringVar(&tieBreak, "tie-break", "default", "choice among co-optimal allignments: default, left (gaps), right (gaps) or match")
+	flag.BoolVar(&countOptimal, "count-optimal", false, "count co-optimal allignments and report if the allignment is unique, pair mode only")
+	flag.IntVar(&enumOptimal, "enum-optimal", 0, "print up to this amount of co-optimal allignments, pair mode only")
+	flag.IntVar(&bandWidth, "band", 0, "width of band around diagonal, widened until the allignment is optimal, 0 is the whole table")
+	flag.IntVar(&wordSize, "word", 0, "word size of index: 3 for protein and 11 for DNA if 0")
+	flag.StringVar(&seedMask, "seed", "", "spaced seed pattern of index, e.g. 110101, overrides -word")
+	flag.Float64Var(&neighbor, "neighbor", 11, "lowest score of neighborhood words of protein query in search mode")
+	flag.Float64Var(&xDrop, "xdrop", 16, "X of ungapped extension of seed hits in search mode (20 for DNA)")
+	flag.Float64Var(&xDropGapped, "xdrop-gapped", 38, "X of gapped extension in search mode (50 for DNA)")
+	flag.Float64Var(&minUngapped, "min-ungapped", 20, "lowest score of ungapped extension extended with gaps in search mode (25 for DNA)")
+	flag.IntVar(&dbSize, "db-size", 0, "amount of residues of database for E-values of local allignments, 0 for the actual one")
+	flag.BoolVar(&estStats, "estimate-stats", false, "estimate E-value parameters by allignments of random sequences if there are no pre-fitted ones")
+	flag.IntVar(&shuffles, "shuffles", 500, "amount of shuffles of the second sequence in shuffle mode")
+	flag.StringVar(&shuffleKind, "shuffle-kind", "plain", "shuffle of shuffle mode: plain (keeps composition) or dinucleotide (keeps pairs of adjacent residues)")
+	flag.Int64Var(&shuffleSeed, "shuffle-seed", 1, "seed of random shuffles, the same seed gives the same result")
+	flag.StringVar(&freeEnds, "free-ends", "", "semi-global alignment, comma separated list of ends with free gaps: a-start, a-end, b-start, b-end or all")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %[1]s:\n%[1]s {-flag [val]} file [file2]\n", os.Args[0])
 		flag.PrintDefaults()
@@ -42,3 +77,36 @@ func isFlagPassed(name string) bool {
 func isGapExtPassed() bool {
 	return isFlagPassed("gap-extend") || isFlagPassed("ge")
 }
+
+var shuffleKinds = map[string]sequence.ShuffleKind{
+	"plain":        sequence.ShufflePlain,
+	"dinucleotide": sequence.ShuffleDinucleotide,
+}
+
+var tieBreaks = map[string]sequence.TieBreak{
+	"default": sequence.TieDefault,
+	"left":    sequence.TieLeftGaps,
+	"right":   sequence.TieRightGaps,
+	"match":   sequence.TiePreferMatch,
+}
+
+func parseFreeEnds(s string) (sequence.FreeEnds, error) {
+	ends := sequence.FreeEnds{}
+	for _, end := range strings.Split(s, ",") {
+		switch strings.TrimSpace(end) {
+		case "a-start":
+			ends.StartA = true
+		case "a-end":
+			ends.EndA = true
+		case "b-start":
+			ends.StartB = true
+		case "b-end":
+			ends.EndB = true
+		case "all":
+			ends = sequence.FreeEnds{StartA: true, EndA: true, StartB: true, EndB: true}
+		default:
+			return ends, fmt.Errorf("unknown end %q", end)
+		}
+	}
+	return ends, nil
+}
@@ -3,7 +3,8 @@ module lab2
 go 1.14
 
 require (
-	github.com/fatih/color v1.9.0 // indirect
+	github.com/fatih/color v1.9.0
+	github.com/klauspost/compress v1.11.13
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.6.1
 )
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderParser gets ID and description from FASTA header line without leading '>' and line ending
+type HeaderParser interface {
+	ParseHeader(h string) (id, descr string, err error)
+}
+
+// HeaderParserFunc is a function used as HeaderParser
+type HeaderParserFunc func(h string) (string, string, error)
+
+// ParseHeader calls f(h)
+func (f HeaderParserFunc) ParseHeader(h string) (string, string, error) {
+	return f(h)
+}
+
+// Header parsers of known styles
+var (
+	// PlainHeaderParser takes the first word as ID and the rest as description: ">seq1 some text"
+	PlainHeaderParser = HeaderParserFunc(parsePlainHeader)
+	// NCBIHeaderParser parses "db|accession" pairs and takes the last accession as ID:
+	// ">gi|4504347|ref|NP_000549.1| hemoglobin alpha"
+	NCBIHeaderParser = HeaderParserFunc(parseNCBIHeader)
+	// UniProtHeaderParser parses ">sp|P69905|HBA_HUMAN Hemoglobin subunit alpha",
+	// accession is ID, the rest is description
+	UniProtHeaderParser = HeaderParserFunc(parseUniProtHeader)
+	// AutoHeaderParser selects one of the parsers above by header content
+	AutoHeaderParser = HeaderParserFunc(parseAutoHeader)
+)
+
+var headerParsers = map[string]HeaderParser{
+	"auto":    AutoHeaderParser,
+	"plain":   PlainHeaderParser,
+	"ncbi":    NCBIHeaderParser,
+	"uniprot": UniProtHeaderParser,
+}
+
+func parsePlainHeader(h string) (string, string, error) {
+	fields := strings.Fields(h)
+	if len(fields) == 0 {
+		return "", "", ErrBadHeader
+	}
+	return fields[0], strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(h), fields[0])), nil
+}
+
+func parseNCBIHeader(h string) (string, string, error) {
+	info := strings.Split(strings.TrimSpace(h), "|")
+	if len(info) < 3 {
+		return "", "", errors.Wrap(ErrBadHeader, "ncbi header needs at least one db|accession pair")
+	}
+	id := ""
+	i := 0
+	for ; i+1 < len(info); i += 2 {
+		db, acc := info[i], strings.TrimSpace(info[i+1])
+		if db == "" || strings.ContainsAny(db, " \t") {
+			break
+		}
+		if acc != "" && (id == "" || db != "gi") {
+			id = acc
+		}
+	}
+	if id == "" {
+		return "", "", errors.Wrap(ErrBadHeader, "no accession in ncbi header")
+	}
+	return id, strings.TrimSpace(strings.Join(info[i:], "|")), nil
+}
+
+func parseUniProtHeader(h string) (string, string, error) {
+	info := strings.SplitN(h, "|", 3)
+	if len(info) != 3 || info[1] == "" {
+		return "", "", errors.Wrap(ErrBadHeader, "uniprot header should be db|accession|name")
+	}
+	return info[1], strings.TrimSpace(info[2]), nil
+}
+
+// parseAutoHeader selects style by the first word, so '|' in description does not change it
+func parseAutoHeader(h string) (string, string, error) {
+	fields := strings.Fields(h)
+	if len(fields) == 0 {
+		return "", "", ErrBadHeader
+	}
+	first := fields[0]
+	switch {
+	case strings.HasPrefix(first, "sp|"), strings.HasPrefix(first, "tr|"), strings.Count(first, "|") == 2:
+		return parseUniProtHeader(h)
+	case strings.HasPrefix(first, "gi|"), strings.Count(first, "|") > 2:
+		return parseNCBIHeader(h)
+	}
+	return parsePlainHeader(h)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAutoHeader(t *testing.T) {
+	tcs := []struct {
+		header string
+		id     string
+		descr  string
+	}{
+		{"seq1", "seq1", ""},
+		{"seq1 some text", "seq1", "some text"},
+		{"gi|4504347|ref|NP_000549.1| hemoglobin alpha", "NP_000549.1", "hemoglobin alpha"},
+		{"gi|4504347|ref|NP_000549.1| hemoglobin a|b", "NP_000549.1", "hemoglobin a|b"},
+		{"sp|P69905|HBA_HUMAN Hemoglobin subunit alpha", "P69905", "HBA_HUMAN Hemoglobin subunit alpha"},
+		{"tr|A0A024R161|A0A024R161_HUMAN Guanine|nucleotide", "A0A024R161", "A0A024R161_HUMAN Guanine|nucleotide"},
+		// pipes only in description
+		{"seq1 a|b", "seq1", "a|b"},
+		{"seq1 a|b|c", "seq1", "a|b|c"},
+		{"seq1 x|y|z|w|v", "seq1", "x|y|z|w|v"},
+	}
+	for _, tc := range tcs {
+		id, descr, err := AutoHeaderParser.ParseHeader(tc.header)
+		require.NoError(t, err, tc.header)
+		require.Equal(t, tc.id, id, tc.header)
+		require.Equal(t, tc.descr, descr, tc.header)
+	}
+
+	_, _, err := AutoHeaderParser.ParseHeader(" ")
+	require.Equal(t, ErrBadHeader, errors.Cause(err))
+}
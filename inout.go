@@ -14,23 +14,80 @@ import (
 )
 
 func readSeqsFromFile(filename string) ([]*AminoSequence, error) {
-	f, err := os.Open(filename)
+	seqs := make([]*AminoSequence, 0)
+	err := forEachSeq(filename, func(seq *AminoSequence) error {
+		seqs = append(seqs, seq)
+		return nil
+	})
+	return seqs, err
+}
+
+// forEachSeq streams sequences of file to fn without keeping them in memory
+func forEachSeq(filename string, fn func(seq *AminoSequence) error) error {
+	f, err := openInput(filename)
 	if err != nil {
-		return nil, errors.Wrap(err, "opening file "+filename)
+		return err
 	}
-	seqs := make([]*AminoSequence, 0)
-	p := NewFastaParser(f)
-	for {
+	defer f.Close()
+	hp, ok := headerParsers[headerStyle]
+	if !ok {
+		fatal("unknown header style %q", headerStyle)
+	}
+	p, err := NewSeqParser(f, hp)
+	if err != nil {
+		return errors.Wrap(err, "reading file "+filename)
+	}
+	for record := 1; ; record++ {
 		seq, err := p.Next()
 		if err != nil {
 			if err == io.EOF {
-				break
+				return nil
 			}
-			fatal("processing error: %s", err)
+			return errors.Wrapf(err, "%s: record %d", filename, record)
+		}
+		if err := fn(seq); err != nil {
+			return err
 		}
-		seqs = append(seqs, seq)
 	}
-	return seqs, nil
+}
+
+func readMatrixFromFile(filename string, gapOpen, gapExtend float64) sequence.Alligner {
+	f, err := os.Open(filename)
+	if err != nil {
+		fatal(errors.Wrap(err, "opening file "+filename).Error())
+	}
+	defer f.Close()
+	allg, err := sequence.ParseMatrix(f, gapOpen, gapExtend)
+	if err != nil {
+		fatal(errors.Wrap(err, "reading matrix "+filename).Error())
+	}
+	return allg
+}
+
+func readPSSMFromFile(filename string) *sequence.PSSM {
+	f, err := os.Open(filename)
+	if err != nil {
+		fatal(errors.Wrap(err, "opening file "+filename).Error())
+	}
+	defer f.Close()
+	pssm, err := sequence.ParsePSSM(f)
+	if err != nil {
+		fatal(errors.Wrap(err, "reading pssm "+filename).Error())
+	}
+	return pssm
+}
+
+func readGapsFromFile(filename string, l int) *sequence.PositionGaps {
+	f, err := os.Open(filename)
+	if err != nil {
+		fatal(errors.Wrap(err, "opening file "+filename).Error())
+	}
+	defer f.Close()
+	gaps, err := sequence.ParsePositionGaps(f, l, gap, gapExt)
+	if err != nil {
+		fatal(errors.Wrap(err, "reading gap penalties "+filename).Error())
+	}
+	return gaps
 }
 
 func readSeqsFromFiles(files []string) (*AminoSequence, *AminoSequence) {
@@ -49,6 +106,9 @@ func readSeqsFromFiles(files []string) (*AminoSequence, *AminoSequence) {
 		seq1, seq2 = seqs[0], seqs[1]
 	}
 	if len(files) == 2 {
+		if files[0] == stdinName && files[1] == stdinName {
+			fatal("stdin can be used only for one input")
+		}
 		seqs1, err := readSeqsFromFile(files[0])
 		if err != nil {
 			fatal(err.Error())
@@ -60,12 +120,32 @@ func readSeqsFromFiles(files []string) (*AminoSequence, *AminoSequence) {
 		if len(seqs1) != 1 || len(seqs2) != 1 {
 			fatal("bad amount of sequeces %d", len(seqs1)+len(seqs2))
 		}
-		seq1, seq2 = seqs1[0], seqs2[1]
+		seq1, seq2 = seqs1[0], seqs2[0]
 	}
 	return seq1, seq2
 }
 
-func formatRes(alg sequence.Alligner, res1, res2 string, v int, withColor bool) string {
+// maskCase returns residues of alligned seq with soft-masked ones in lower case, beg is position of the first residue
+func maskCase(alg sequence.Alligner, alligned string, seq *AminoSequence, beg int) string {
+	if seq.Mask == nil {
+		return alligned
+	}
+	res := []byte(alligned)
+	pos := beg
+	for i := range res {
+		if res[i] == alg.Gap() {
+			continue
+		}
+		if seq.Masked(pos) && res[i] >= 'A' && res[i] <= 'Z' {
+			res[i] = res[i] - 'A' + 'a'
+		}
+		pos++
+	}
+	return string(res)
+}
+
+func formatRes(alg sequence.Alligner, res *sequence.Allignment, seq1, seq2 *AminoSequence, withColor bool) string {
+	res1, res2 := maskCase(alg, res.ResA, seq1, res.BegA), maskCase(alg, res.ResB, seq2, res.BegB)
 	bld1 := strings.Builder{}
 	bldMid := strings.Builder{}
 	bld2 := strings.Builder{}
@@ -89,7 +169,7 @@ func formatRes(alg sequence.Alligner, res1, res2 string, v int, withColor bool)
 		if res1[i] == alg.Gap() || res2[i] == alg.Gap() {
 			col = gapColor
 			conn = " "
-		} else if res1[i] == res2[i] {
+		} else if res.ResA[i] == res.ResB[i] {
 			col = matchColor
 			conn = "|"
 		}
@@ -108,20 +188,36 @@ func formatRes(alg sequence.Alligner, res1, res2 string, v int, withColor bool)
 	bld1.WriteByte('\n')
 	bld1.WriteString(bld2.String())
 	bld1.WriteByte('\n')
-	bld1.WriteString(fmt.Sprintf("score: %d\n", v))
+	bld1.WriteString(fmt.Sprintf("score: %g\n", res.Score))
+	bld1.WriteString(formatStats(res.Score, len(seq1.Value)))
+	if local {
+		bld1.WriteString(fmt.Sprintf("seq1 region: %d-%d\n", res.BegA+1, res.EndA))
+		bld1.WriteString(fmt.Sprintf("seq2 region: %d-%d\n", res.BegB+1, res.EndB))
+	}
+	if seq1.Header != "" || seq2.Header != "" {
+		bld1.WriteString(fmt.Sprintf("seq1 header: %s\n", seq1.Header))
+		bld1.WriteString(fmt.Sprintf("seq2 header: %s\n", seq2.Header))
+	}
 
 	return bld1.String()
 }
 
-func printRes(alg sequence.Alligner, res1, res2 string, v int) {
+func printRes(alg sequence.Alligner, res *sequence.Allignment, seq1, seq2 *AminoSequence) {
+	printOut(func(withColor bool) string {
+		return formatRes(alg, res, seq1, seq2, withColor)
+	})
+}
+
+// printOut writes result to output file or colored to stdout
+func printOut(format func(withColor bool) string) {
 	if outFile != "" {
 		f, err := os.Create(outFile)
-		defer f.Close()
 		if err != nil {
 			log.Fatal(errors.Wrap(err, "opening file "+outFile).Error())
 		}
-		fmt.Fprintf(f, formatRes(alg, res1, res2, v, false))
+		defer f.Close()
+		fmt.Fprint(f, format(false))
 		return
 	}
-	fmt.Print(formatRes(alg, res1, res2, v, true))
+	fmt.Print(format(true))
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// stdinName is the file name used for standard input
+const stdinName = "-"
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type inputReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *inputReader) Close() error {
+	var err error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if cerr := r.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type zstdCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// openInput opens file, or stdin if filename is "-".
+// Gzip, bzip2 and zstd compressed input is detected by magic bytes and decompressed.
+func openInput(filename string) (io.ReadCloser, error) {
+	var f io.ReadCloser = ioutil.NopCloser(os.Stdin)
+	if filename != stdinName {
+		var err error
+		f, err = os.Open(filename)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening file "+filename)
+		}
+	}
+	res := &inputReader{closers: []io.Closer{f}}
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		res.Close()
+		return nil, errors.Wrap(err, "reading file "+filename)
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			res.Close()
+			return nil, errors.Wrap(err, "reading gzip file "+filename)
+		}
+		res.Reader = gz
+		res.closers = append(res.closers, gz)
+	case bytes.HasPrefix(magic, bzip2Magic):
+		res.Reader = bzip2.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			res.Close()
+			return nil, errors.Wrap(err, "reading zstd file "+filename)
+		}
+		res.Reader = zr
+		res.closers = append(res.closers, zstdCloser{zr})
+	default:
+		res.Reader = br
+	}
+	return res, nil
+}
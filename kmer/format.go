@@ -0,0 +1,166 @@
+package kmer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Version is version of binary index format written by Write
+const Version = 1
+
+// magic starts index file
+const magic = "KMIX"
+
+// Errors of Read
+var (
+	ErrBadIndex     = errors.New("bad index file")
+	ErrIndexVersion = errors.New("unsupported index version")
+)
+
+// Binary format, little endian, strings are uint32 length and bytes:
+//
+//	magic "KMIX", uint32 version, uint8 kind, string seed pattern,
+//	uint32 amount of records, records: string ID, string header, string sequence,
+//	uint32 amount of words, words in increasing order of code:
+//	uint64 code, uint32 amount of postings, postings: uint32 record, uint32 position.
+
+type indexWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *indexWriter) write(v interface{}) {
+	if iw.err == nil {
+		iw.err = binary.Write(iw.w, binary.LittleEndian, v)
+	}
+}
+
+func (iw *indexWriter) writeString(s string) {
+	iw.write(uint32(len(s)))
+	if iw.err == nil {
+		_, iw.err = iw.w.WriteString(s)
+	}
+}
+
+// Write writes index in binary format of Version
+func (ix *Index) Write(w io.Writer) error {
+	iw := &indexWriter{w: bufio.NewWriter(w)}
+	iw.write([]byte(magic))
+	iw.write(uint32(Version))
+	iw.write(uint8(ix.Kind))
+	iw.writeString(ix.Seed)
+	iw.write(uint32(len(ix.Records)))
+	for _, rec := range ix.Records {
+		iw.writeString(rec.ID)
+		iw.writeString(rec.Header)
+		iw.writeString(rec.Seq)
+	}
+	codes := ix.sortedCodes()
+	iw.write(uint32(len(codes)))
+	for _, code := range codes {
+		postings := ix.words[code]
+		iw.write(code)
+		iw.write(uint32(len(postings)))
+		iw.write(postings)
+	}
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+type indexReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (ir *indexReader) read(v interface{}) {
+	if ir.err == nil {
+		ir.err = binary.Read(ir.r, binary.LittleEndian, v)
+	}
+}
+
+func (ir *indexReader) readUint32() uint32 {
+	var v uint32
+	ir.read(&v)
+	return v
+}
+
+func (ir *indexReader) readString() string {
+	l := ir.readUint32()
+	if ir.err != nil {
+		return ""
+	}
+	buf := make([]byte, 0, minUint32(l, 1<<20))
+	for uint32(len(buf)) < l && ir.err == nil {
+		chunk := make([]byte, minUint32(l-uint32(len(buf)), 1<<20))
+		_, ir.err = io.ReadFull(ir.r, chunk)
+		buf = append(buf, chunk...)
+	}
+	return string(buf)
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Read reads index written by Write
+func Read(r io.Reader) (*Index, error) {
+	ir := &indexReader{r: bufio.NewReader(r)}
+	head := make([]byte, len(magic))
+	ir.read(head)
+	if ir.err != nil || string(head) != magic {
+		return nil, errors.Wrap(ErrBadIndex, "no index header")
+	}
+	version := ir.readUint32()
+	if ir.err == nil && version != Version {
+		return nil, errors.Wrapf(ErrIndexVersion, "version %d, supported %d", version, Version)
+	}
+	var kind uint8
+	ir.read(&kind)
+	seed := ir.readString()
+	if ir.err != nil {
+		return nil, errors.Wrapf(ErrBadIndex, "header: %s", ir.err)
+	}
+	ix, err := newIndex(Kind(kind), seed)
+	if err != nil {
+		return nil, errors.Wrapf(ErrBadIndex, "header: %s", err)
+	}
+
+	n := ir.readUint32()
+	for i := uint32(0); i < n && ir.err == nil; i++ {
+		ix.Records = append(ix.Records, Record{ID: ir.readString(), Header: ir.readString(), Seq: ir.readString()})
+	}
+	words := ir.readUint32()
+	for i := uint32(0); i < words && ir.err == nil; i++ {
+		var code uint64
+		ir.read(&code)
+		l := ir.readUint32()
+		// postings are read by chunks, so bad length does not allocate much memory
+		postings := make([]Posting, 0, minUint32(l, 1<<16))
+		for uint32(len(postings)) < l && ir.err == nil {
+			chunk := make([]Posting, minUint32(l-uint32(len(postings)), 1<<16))
+			ir.read(chunk)
+			postings = append(postings, chunk...)
+		}
+		if ir.err != nil {
+			break
+		}
+		for _, p := range postings {
+			if p.Record >= n || uint64(p.Pos)+uint64(len(seed)) > uint64(len(ix.Records[p.Record].Seq)) {
+				return nil, errors.Wrapf(ErrBadIndex, "word %d: position %d of record %d is out of records", code, p.Pos, p.Record)
+			}
+		}
+		ix.words[code] = postings
+	}
+	if ir.err != nil {
+		return nil, errors.Wrapf(ErrBadIndex, "%s", ir.err)
+	}
+	return ix, nil
+}
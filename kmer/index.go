@@ -0,0 +1,92 @@
+// Package kmer indexes words (k-mers or spaced seeds) of database sequences
+// and searches query against the index with seed-and-extend heuristic
+package kmer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a database sequence
+type Record struct {
+	ID     string
+	Header string
+	Seq    string
+}
+
+// Posting is position of a word in a record
+type Posting struct {
+	Record uint32
+	Pos    uint32
+}
+
+// Index has positions of all words of records. Word is residues at '1' positions of Seed pattern,
+// e.g. "11011" is spaced seed of 4 residues with ignored middle one.
+type Index struct {
+	Kind    Kind
+	Seed    string
+	Records []Record
+	words   map[uint64][]Posting
+	coder   *wordCoder
+}
+
+// Build indexes records, seed is pattern of words (see ContiguousSeed)
+func Build(kind Kind, seed string, records []Record) (*Index, error) {
+	ix, err := newIndex(kind, seed)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(records)) > math.MaxUint32 {
+		return nil, errors.Errorf("too many records: %d", len(records))
+	}
+	ix.Records = records
+	for r, rec := range records {
+		if uint64(len(rec.Seq)) > math.MaxUint32 {
+			return nil, errors.Errorf("record %s is too long: %d", rec.ID, len(rec.Seq))
+		}
+		for pos := 0; pos+len(seed) <= len(rec.Seq); pos++ {
+			code, ok := ix.coder.code(rec.Seq, pos)
+			if ok {
+				ix.words[code] = append(ix.words[code], Posting{Record: uint32(r), Pos: uint32(pos)})
+			}
+		}
+	}
+	return ix, nil
+}
+
+func newIndex(kind Kind, seed string) (*Index, error) {
+	coder, err := newWordCoder(kind, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{
+		Kind:  kind,
+		Seed:  seed,
+		words: make(map[uint64][]Posting),
+		coder: coder,
+	}, nil
+}
+
+// Words returns amount of distinct words in index
+func (ix *Index) Words() int {
+	return len(ix.words)
+}
+
+// lookup returns positions of word with code
+func (ix *Index) lookup(code uint64) []Posting {
+	return ix.words[code]
+}
+
+// sortedCodes returns codes of words in increasing order
+func (ix *Index) sortedCodes() []uint64 {
+	codes := make([]uint64, 0, len(ix.words))
+	for code := range ix.words {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+	return codes
+}
@@ -0,0 +1,197 @@
+package kmer
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"lab2/sequence"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func randomSeq(r *rand.Rand, alphabet string, l int) string {
+	res := make([]byte, l)
+	for i := range res {
+		res[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(res)
+}
+
+// mutate changes about rate of residues of s and inserts one residue in the middle
+func mutate(r *rand.Rand, alphabet, s string, rate float64) string {
+	res := []byte(s)
+	for i := range res {
+		if r.Float64() < rate {
+			res[i] = alphabet[r.Intn(len(alphabet))]
+		}
+	}
+	mid := len(res) / 2
+	return string(res[:mid]) + alphabet[:1] + string(res[mid:])
+}
+
+func randomRecords(r *rand.Rand, alphabet string, n, l int) []Record {
+	res := make([]Record, n)
+	for i := range res {
+		res[i] = Record{ID: fmt.Sprintf("rec%d", i), Header: fmt.Sprintf("rec%d random", i), Seq: randomSeq(r, alphabet, l)}
+	}
+	return res
+}
+
+func TestWordCoder(t *testing.T) {
+	wc, err := newWordCoder(DNA, "1101")
+	require.NoError(t, err)
+	code, ok := wc.code("ACGT", 0)
+	require.True(t, ok)
+	// A C . T
+	require.Equal(t, uint64(0*16+1*4+3), code)
+	same, ok := wc.code("acaU", 0)
+	require.True(t, ok)
+	require.Equal(t, code, same)
+	_, ok = wc.code("ACNT", 0)
+	require.True(t, ok)
+	_, ok = wc.code("ANGT", 0)
+	require.False(t, ok)
+
+	for _, bad := range []string{"", "0110", "110", "1a1", ContiguousSeed(40)} {
+		_, err = newWordCoder(DNA, bad)
+		require.Equal(t, ErrBadSeed, errors.Cause(err), bad)
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	allg := sequence.NewAlligerBLOSUM62(-11, -1)
+	wc, err := newWordCoder(Protein, "101")
+	require.NoError(t, err)
+	query := "WKC"
+	words := wc.neighbors(allg, ProteinAlphabet, query, 0, 13)
+
+	var exp []uint64
+	for x := 0; x < len(ProteinAlphabet); x++ {
+		for y := 0; y < len(ProteinAlphabet); y++ {
+			if allg.Compare('W', ProteinAlphabet[x])+allg.Compare('C', ProteinAlphabet[y]) >= 13 {
+				exp = append(exp, uint64(x*20+y))
+			}
+		}
+	}
+	require.Equal(t, exp, words)
+	self, _ := wc.code(query, 0)
+	require.Contains(t, words, self)
+
+	require.Equal(t, []uint64{self}, wc.neighbors(allg, ProteinAlphabet, query, 0, 0))
+	require.Empty(t, wc.neighbors(allg, ProteinAlphabet, "XKC", 0, 13))
+
+	// PSSM scores only K at the middle of query high, words are scored by positions of query
+	scores := make([][]float64, 5)
+	for i := range scores {
+		scores[i] = make([]float64, len(ProteinAlphabet))
+		for r := range scores[i] {
+			scores[i][r] = -5
+		}
+	}
+	scores[3][strings.IndexByte(ProteinAlphabet, 'K')] = 20
+	pssm, err := sequence.NewPSSM("AAWKC", []byte(ProteinAlphabet), scores)
+	require.NoError(t, err)
+	pssmAllg := sequence.NewPSSMAlligner(allg, pssm)
+	wc, err = newWordCoder(Protein, "11")
+	require.NoError(t, err)
+	k := uint64(strings.IndexByte(ProteinAlphabet, 'K'))
+	words = wc.neighbors(pssmAllg, ProteinAlphabet, "AAWKC", 2, 15)
+	require.Len(t, words, len(ProteinAlphabet))
+	for x, code := range words {
+		require.Equal(t, uint64(x)*20+k, code)
+	}
+	require.Empty(t, wc.neighbors(pssmAllg, ProteinAlphabet, "AAWKC", 0, 15))
+}
+
+func TestWriteRead(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ix, err := Build(Protein, "11011", randomRecords(r, ProteinAlphabet+"X", 20, 100))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, ix.Write(buf))
+	data := buf.Bytes()
+	res, err := Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, ix.Kind, res.Kind)
+	require.Equal(t, ix.Seed, res.Seed)
+	require.Equal(t, ix.Records, res.Records)
+	require.Equal(t, ix.words, res.words)
+
+	_, err = Read(bytes.NewReader(data[:len(data)-3]))
+	require.Equal(t, ErrBadIndex, errors.Cause(err))
+	_, err = Read(bytes.NewReader([]byte("ACGT")))
+	require.Equal(t, ErrBadIndex, errors.Cause(err))
+
+	future := append([]byte(nil), data...)
+	future[4] = Version + 1
+	_, err = Read(bytes.NewReader(future))
+	require.Equal(t, ErrIndexVersion, errors.Cause(err))
+}
+
+func TestRecordHits(t *testing.T) {
+	rh := newRecordHits()
+	// pairs (2, 5), (3, 6), then gap in b moves to diagonal 2, pairs (5, 7), (6, 8)
+	rh.add(&sequence.Allignment{ResA: "ACGTA", ResB: "AC-TA", BegA: 2, EndA: 7, BegB: 5, EndB: 9}, '-')
+	require.Equal(t, map[int][]span{3: {{2, 4}}, 2: {{5, 7}}}, rh.extended)
+	for _, p := range [][2]int{{2, 5}, {3, 6}, {5, 7}, {6, 8}} {
+		require.True(t, rh.covered(p[0], p[1]), p)
+	}
+	for _, p := range [][2]int{{4, 7}, {4, 6}, {7, 9}, {1, 4}, {2, 6}} {
+		require.False(t, rh.covered(p[0], p[1]), p)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	tcs := []struct {
+		allg     sequence.Alligner
+		kind     Kind
+		alphabet string
+		seed     string
+	}{
+		{sequence.NewAlligerBLOSUM62(-11, -1), Protein, ProteinAlphabet, "111"},
+		{sequence.NewAlligerBLOSUM62(-11, -1), Protein, ProteinAlphabet, "1101"},
+		{sequence.NewAlligerDNA(-10, -1), DNA, DNAAlphabet, ContiguousSeed(11)},
+		{sequence.NewAlligerDNA(-10, -1), DNA, DNAAlphabet, "110110110110111"},
+	}
+	for _, tc := range tcs {
+		records := randomRecords(r, tc.alphabet, 50, 150)
+		query := randomSeq(r, tc.alphabet, 80)
+		rate := 0.2
+		if tc.kind == DNA {
+			rate = 0.05
+		}
+		// homolog is a mutated query inside of random sequence
+		records[17].Seq = records[17].Seq[:40] + mutate(r, tc.alphabet, query, rate) + records[17].Seq[40:]
+		ix, err := Build(tc.kind, tc.seed, records)
+		require.NoError(t, err)
+
+		opts := DefaultSearchOptions(tc.kind)
+		opts.Top = 3
+		opts.Options = sequence.Options{Mode: sequence.ModeLocal, Threads: 1}
+		hits, err := ix.Search(tc.allg, query, opts)
+		require.NoError(t, err)
+		require.NotEmpty(t, hits, tc.seed)
+		require.LessOrEqual(t, len(hits), 3)
+		require.Equal(t, 17, hits[0].Record, tc.seed)
+
+		exp, err := sequence.AllignWithOptions(tc.allg, query, records[17].Seq, opts.Options)
+		require.NoError(t, err)
+		require.Equal(t, exp, hits[0].Allignment)
+		require.LessOrEqual(t, hits[0].Extension.Score, exp.Score)
+		require.Greater(t, hits[0].Allignment.Score, 2*opts.MinUngapped)
+		for i := 1; i < len(hits); i++ {
+			require.GreaterOrEqual(t, hits[i-1].Allignment.Score, hits[i].Allignment.Score)
+		}
+	}
+
+	ix, err := Build(DNA, "111", []Record{{ID: "a", Seq: "ACGTNNACGT"}})
+	require.NoError(t, err)
+	_, err = ix.Search(sequence.NewAlligerDNA(-10, -1), "ACGJ", DefaultSearchOptions(DNA))
+	require.Equal(t, sequence.ErrBadSeq, errors.Cause(err))
+}
@@ -0,0 +1,184 @@
+package kmer
+
+import (
+	"math"
+	"sort"
+
+	"lab2/sequence"
+
+	"github.com/pkg/errors"
+)
+
+// SearchOptions configures Search
+type SearchOptions struct {
+	// Threshold is the lowest score of neighborhood word against word of query under the Alligner,
+	// 0 is only the word itself. DNA index always uses only the word itself.
+	Threshold float64
+	// XDrop is X of ungapped extension of seed hits
+	XDrop float64
+	// MinUngapped is the lowest score of ungapped extension to be extended with gaps
+	MinUngapped float64
+	// XDropGapped is X of gapped extension
+	XDropGapped float64
+	// Top is amount of the best records alligned with full DP, 0 for all records with gapped extension
+	Top int
+	// Options of full DP allignment
+	Options sequence.Options
+}
+
+// DefaultSearchOptions returns options close to BLAST defaults for kind of index
+func DefaultSearchOptions(kind Kind) SearchOptions {
+	if kind == DNA {
+		return SearchOptions{XDrop: 20, MinUngapped: 25, XDropGapped: 50, Top: 10}
+	}
+	return SearchOptions{Threshold: 11, XDrop: 16, MinUngapped: 20, XDropGapped: 38, Top: 10}
+}
+
+// Hit is a record found by Search
+type Hit struct {
+	Record int
+	// Extension is the best gapped extension of seed hits of the record
+	Extension *sequence.Allignment
+	// Allignment is full DP allignment of query and record
+	Allignment *sequence.Allignment
+}
+
+// span is a part [beg, end) of query on a diagonal
+type span struct {
+	beg, end int
+}
+
+// recordHits keeps extensions of seed hits of one record
+type recordHits struct {
+	// diags has end of the last ungapped extension in query by diagonal, hits before it are skipped
+	diags map[int]int
+	// extended has parts of query alligned on diagonal by gapped extensions
+	extended map[int][]span
+	best     *sequence.Allignment
+	bad      bool
+}
+
+func newRecordHits() *recordHits {
+	return &recordHits{diags: make(map[int]int), extended: make(map[int][]span)}
+}
+
+// covered reports if seed pair is on the path of a gapped extension already
+func (rh *recordHits) covered(i, j int) bool {
+	for _, s := range rh.extended[j-i] {
+		if s.beg <= i && i < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// add adds aligned pairs of gapped extension to extended diagonals
+func (rh *recordHits) add(ext *sequence.Allignment, gap byte) {
+	i, j := ext.BegA, ext.BegB
+	var cur *span
+	curDiag := 0
+	for k := 0; k < len(ext.ResA); k++ {
+		switch {
+		case ext.ResA[k] == gap:
+			j++
+			cur = nil
+		case ext.ResB[k] == gap:
+			i++
+			cur = nil
+		default:
+			if cur == nil || curDiag != j-i {
+				curDiag = j - i
+				rh.extended[curDiag] = append(rh.extended[curDiag], span{beg: i, end: i})
+				spans := rh.extended[curDiag]
+				cur = &spans[len(spans)-1]
+			}
+			cur.end = i + 1
+			i++
+			j++
+		}
+	}
+}
+
+// Search finds records similar to query: words of query (and neighborhood words for protein index)
+// give seed hits on diagonals, seeds are extended without gaps and the best of them with gaps (X-drop),
+// then opts.Top records with the best gapped extensions are alligned with full DP of opts.Options.
+// Hits are in decreasing order of score of full allignment.
+func (ix *Index) Search(alg sequence.Alligner, query string, opts SearchOptions) ([]Hit, error) {
+	if opts.Threshold < 0 || opts.XDrop < 0 || opts.XDropGapped < 0 || opts.Top < 0 ||
+		math.IsNaN(opts.XDrop) || math.IsNaN(opts.XDropGapped) {
+		return nil, errors.New("negative or NaN search option")
+	}
+	ext, err := sequence.NewExtender(alg, query)
+	if err != nil {
+		return nil, errors.WithMessage(err, "query")
+	}
+	threshold := opts.Threshold
+	if ix.Kind == DNA {
+		threshold = 0
+	}
+
+	records := make(map[uint32]*recordHits)
+	for q := 0; q+len(ix.Seed) <= len(query); q++ {
+		for _, code := range ix.coder.neighbors(alg, ix.Kind.alphabet(), query, q, threshold) {
+			for _, p := range ix.lookup(code) {
+				seq := ix.Records[p.Record].Seq
+				rh := records[p.Record]
+				if rh == nil {
+					rh = newRecordHits()
+					// record with residues out of alphabet of alg is skipped
+					rh.bad = ext.Check(seq) != nil
+					records[p.Record] = rh
+				}
+				pos := int(p.Pos)
+				if rh.bad || q < rh.diags[pos-q] {
+					continue
+				}
+				ungapped := ext.Ungapped(seq, q, pos, opts.XDrop)
+				rh.diags[pos-q] = ungapped.EndA
+				if ungapped.Score < opts.MinUngapped || rh.covered(q, pos) {
+					continue
+				}
+				gapped := ext.Gapped(seq, q, pos, opts.XDropGapped)
+				rh.add(gapped, alg.Gap())
+				if rh.best == nil || gapped.Score > rh.best.Score {
+					rh.best = gapped
+				}
+			}
+		}
+	}
+
+	var hits []Hit
+	for r, rh := range records {
+		if rh.best != nil {
+			hits = append(hits, Hit{Record: int(r), Extension: rh.best})
+		}
+	}
+	sortHits(hits, func(h Hit) float64 {
+		return h.Extension.Score
+	})
+	if opts.Top > 0 && len(hits) > opts.Top {
+		hits = hits[:opts.Top]
+	}
+	for i := range hits {
+		res, err := sequence.AllignWithOptions(alg, query, ix.Records[hits[i].Record].Seq, opts.Options)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "record %s", ix.Records[hits[i].Record].ID)
+		}
+		hits[i].Allignment = res
+	}
+	sortHits(hits, func(h Hit) float64 {
+		return h.Allignment.Score
+	})
+	return hits, nil
+}
+
+// sortHits sorts hits by decreasing score, ties are kept in order of records
+func sortHits(hits []Hit, score func(h Hit) float64) {
+	sort.Slice(hits, func(i, j int) bool {
+		si, sj := score(hits[i]), score(hits[j])
+		if si != sj {
+			return si > sj
+		}
+		return hits[i].Record < hits[j].Record
+	})
+}
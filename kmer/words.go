@@ -0,0 +1,173 @@
+package kmer
+
+import (
+	"math"
+
+	"lab2/sequence"
+
+	"github.com/pkg/errors"
+)
+
+// Kind is kind of residues of indexed sequences
+type Kind uint8
+
+// Kinds of index
+const (
+	Protein Kind = iota
+	DNA
+)
+
+// Alphabets of words, residues not in alphabet (ambiguity codes, X, *) do not make words
+const (
+	ProteinAlphabet = "ARNDCQEGHILKMFPSTWYV"
+	DNAAlphabet     = "ACGT"
+)
+
+// ErrBadSeed is returned for bad spaced seed pattern
+var ErrBadSeed = errors.New("bad seed pattern")
+
+func (k Kind) String() string {
+	switch k {
+	case Protein:
+		return "protein"
+	case DNA:
+		return "DNA"
+	}
+	return "unknown"
+}
+
+func (k Kind) alphabet() string {
+	if k == DNA {
+		return DNAAlphabet
+	}
+	return ProteinAlphabet
+}
+
+// ContiguousSeed returns pattern of a word of w residues
+func ContiguousSeed(w int) string {
+	res := make([]byte, w)
+	for i := range res {
+		res[i] = '1'
+	}
+	return string(res)
+}
+
+// wordCoder codes words of spaced seed as numbers: residues at '1' positions of pattern
+// are digits of base len(alphabet), residues at '0' positions are ignored
+type wordCoder struct {
+	pattern string
+	care    []int
+	codes   [256]int8
+	size    int
+}
+
+func newWordCoder(kind Kind, pattern string) (*wordCoder, error) {
+	if kind != Protein && kind != DNA {
+		return nil, errors.Errorf("unknown index kind %d", kind)
+	}
+	if pattern == "" || pattern[0] != '1' || pattern[len(pattern)-1] != '1' {
+		return nil, errors.Wrapf(ErrBadSeed, "%q should start and end with 1", pattern)
+	}
+	wc := &wordCoder{pattern: pattern}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '1':
+			wc.care = append(wc.care, i)
+		case '0':
+		default:
+			return nil, errors.Wrapf(ErrBadSeed, "%q has symbol %q, only 0 and 1 are allowed", pattern, pattern[i])
+		}
+	}
+	alphabet := kind.alphabet()
+	if float64(len(wc.care))*math.Log2(float64(len(alphabet))) > 62 {
+		return nil, errors.Wrapf(ErrBadSeed, "%q has too many positions", pattern)
+	}
+	for i := range wc.codes {
+		wc.codes[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		wc.codes[alphabet[i]] = int8(i)
+		wc.codes[alphabet[i]-'A'+'a'] = int8(i)
+	}
+	if kind == DNA {
+		wc.codes['U'], wc.codes['u'] = wc.codes['T'], wc.codes['T']
+	}
+	wc.size = len(alphabet)
+	return wc, nil
+}
+
+// code returns code of word starting at s[pos], false if it has residue out of alphabet
+func (wc *wordCoder) code(s string, pos int) (uint64, bool) {
+	var code uint64
+	for _, p := range wc.care {
+		c := wc.codes[s[pos+p]]
+		if c < 0 {
+			return 0, false
+		}
+		code = code*uint64(wc.size) + uint64(c)
+	}
+	return code, true
+}
+
+// unknownPos is position of database residue when neighborhood words are scored, it is past the end
+// of any sequence, so PositionalAlligner applies only scores of query positions (e.g. PSSM)
+const unknownPos = math.MaxInt32
+
+// neighbors returns codes of words which score at least threshold against word of query at pos
+// under alg (neighborhood words), only the word itself if threshold is not positive.
+// PositionalAlligner scores residues by CompareAt with their positions in query.
+// Words are returned in increasing order of codes.
+func (wc *wordCoder) neighbors(alg sequence.Alligner, alphabet string, query string, pos int, threshold float64) []uint64 {
+	self, ok := wc.code(query, pos)
+	if !ok {
+		return nil
+	}
+	if threshold <= 0 {
+		return []uint64{self}
+	}
+
+	// scores[p][r] is score of residue r of alphabet against query residue of the p-th care position,
+	// best[p] is the best score of positions from p to the end
+	cmp := func(_ int, a, b byte) float64 {
+		return alg.Compare(a, b)
+	}
+	if p, ok := alg.(sequence.PositionalAlligner); ok {
+		cmp = func(i int, a, b byte) float64 {
+			return p.CompareAt(i, a, unknownPos, b)
+		}
+	}
+	w := len(wc.care)
+	scores := make([][]float64, w)
+	best := make([]float64, w+1)
+	for p := w - 1; p >= 0; p-- {
+		i := pos + wc.care[p]
+		q := query[i]
+		if q >= 'a' && q <= 'z' {
+			q -= 'a' - 'A'
+		}
+		scores[p] = make([]float64, len(alphabet))
+		top := math.Inf(-1)
+		for r := 0; r < len(alphabet); r++ {
+			scores[p][r] = cmp(i, q, alphabet[r])
+			top = math.Max(top, scores[p][r])
+		}
+		best[p] = best[p+1] + top
+	}
+
+	var res []uint64
+	var walk func(p int, code uint64, score float64)
+	walk = func(p int, code uint64, score float64) {
+		if score+best[p] < threshold {
+			return
+		}
+		if p == w {
+			res = append(res, code)
+			return
+		}
+		for r := range scores[p] {
+			walk(p+1, code*uint64(wc.size)+uint64(r), score+scores[p][r])
+		}
+	}
+	walk(0, 0, 0)
+	return res
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"lab2/sequence"
 	"log"
+	"math/big"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 func main() {
@@ -19,30 +24,216 @@ func main() {
 		gapExt = gap
 	}
 
-	seq1, seq2 := readSeqsFromFiles(files)
+	if pssmFile != "" && runMode != modePair && runMode != modeDB && runMode != modeSearch && runMode != modeShuffle {
+		fatal("-pssm can be used only in pair, db, search and shuffle modes")
+	}
+	if (gapsFile1 != "" && runMode != modePair && runMode != modeDB && runMode != modeShuffle) || (gapsFile2 != "" && runMode != modePair) {
+		fatal("-gaps1 can be used only in pair, db and shuffle modes, -gaps2 only in pair mode")
+	}
 
+	switch runMode {
+	case modePair:
+		runPair(files)
+	case modeDB:
+		runDB(files)
+	case modeAll:
+		runAll(files)
+	case modeTree:
+		runTree(files)
+	case modeMSA:
+		runMSA(files)
+	case modeIndex:
+		runIndex(files)
+	case modeSearch:
+		runSearch(files)
+	case modeShuffle:
+		runShuffle(files)
+	default:
+		fatal("unknown mode %q", runMode)
+	}
+}
+
+func newAlligner() sequence.Alligner {
 	var allg sequence.Alligner
-	switch tableType {
-	case useBlosum:
-		allg = sequence.NewAlligerBLOSUM62(gap, gapExt)
-	case useDefault:
+	switch {
+	case matrixFile != "":
+		allg = readMatrixFromFile(matrixFile, gap, gapExt)
+	case tableType == useDefault:
 		allg = sequence.NewDefaultExteded(gap, gapExt)
-	case useDNA:
-		allg = sequence.NewAlligerDNA(gap, gapExt)
+	case tableType == useDNA:
+		allg = sequence.NewAlligerNucleotide(gap, gapExt, sequence.NucleotideScores{
+			Match:        dnaMatch,
+			Transition:   transition,
+			Transversion: transversion,
+		})
 	default:
-		fatal("bad table type %s", tableType)
+		name := tableType
+		if name == useBlosumOld {
+			name = useBlosum
+		}
+		var err error
+		allg, err = sequence.MatrixByName(name, gap, gapExt)
+		if errors.Cause(err) == sequence.ErrMatrixNotBundled {
+			fatal("matrix %s is not built in, load NCBI file of it by -matrix-file", tableType)
+		}
+		if err != nil {
+			fatal("bad table type %s, known matrices: %s, other ones can be loaded by -matrix-file", tableType, strings.Join(sequence.MatrixNames(), ", "))
+		}
+	}
+	return allg
+}
+
+func newOptions() sequence.Options {
+	opts := sequence.Options{
+		Threads:   amThreads,
+		MemoryOpt: memOpt,
+	}
+	if local && freeEnds != "" {
+		fatal("-local can not be used with -free-ends")
+	}
+	if local {
+		opts.Mode = sequence.ModeLocal
+	}
+	if freeEnds != "" {
+		ends, err := parseFreeEnds(freeEnds)
+		if err != nil {
+			fatal("bad -free-ends: %s", err)
+		}
+		opts.Mode = sequence.ModeSemiGlobal
+		opts.FreeEnds = ends
+	}
+	tb, ok := tieBreaks[tieBreak]
+	if !ok {
+		fatal("unknown tie-break policy %q", tieBreak)
+	}
+	opts.TieBreak = tb
+	if bandWidth < 0 {
+		fatal("-band must not be negative")
+	}
+	if local && bandWidth > 0 {
+		fatal("-local can not be used with -band")
+	}
+	opts.Band = bandWidth
+	return opts
+}
+
+// withGaps adds position-specific gap penalties of -gaps1 and -gaps2 files to opts, seq2 may be nil
+func withGaps(opts sequence.Options, seq1, seq2 *AminoSequence) sequence.Options {
+	if gapsFile1 != "" {
+		opts.GapsA = readGapsFromFile(gapsFile1, len(seq1.Value))
+	}
+	if gapsFile2 != "" && seq2 != nil {
+		opts.GapsB = readGapsFromFile(gapsFile2, len(seq2.Value))
+	}
+	return opts
+}
+
+// queryAlligner scores query by PSSM of -pssm if it is set
+func queryAlligner(allg sequence.Alligner, query *AminoSequence) sequence.Alligner {
+	if pssmFile == "" {
+		return allg
+	}
+	pssm := readPSSMFromFile(pssmFile)
+	if pssm.Query != query.Value {
+		fatal("first sequence %s does not match query of pssm %s", query.ID, pssmFile)
 	}
-	allign := sequence.Allign
-	if memOpt {
-		allign = sequence.AllignMemoryOpt
+	return sequence.NewPSSMAlligner(allg, pssm)
+}
+
+// pairAlligner adds quality and soft-mask scoring of seq1 and seq2 to allg if they are enabled
+func pairAlligner(allg sequence.Alligner, seq1, seq2 *AminoSequence) sequence.Alligner {
+	if useQuality {
+		allg = sequence.NewQualityAlligner(allg, seq1.Quality, seq2.Quality)
+	}
+	if maskWeight != 1 {
+		allg = sequence.NewMaskedAlligner(allg, seq1.Mask, seq2.Mask, maskWeight)
+	}
+	return allg
+}
+
+func runPair(files []string) {
+	seq1, seq2 := readSeqsFromFiles(files)
+	seq1, seq2 = seq1.Ungapped(), seq2.Ungapped()
+
+	allg := pairAlligner(queryAlligner(newAlligner(), seq1), seq1, seq2)
+	opts := withGaps(newOptions(), seq1, seq2)
+	initStats(len(seq2.Value))
+	if kBest != 1 {
+		runLocalBest(allg, opts, seq1, seq2)
+		return
+	}
+	t := time.Now()
+	res, err := sequence.AllignWithOptions(allg, seq1.Value, seq2.Value, opts)
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+	if err != nil {
+		fatal("alligning %s", err.Error())
+	}
+	if !countOptimal && enumOptimal <= 0 {
+		printRes(allg, res, seq1, seq2)
+		return
+	}
+	coOptimal := formatCoOptimal(allg, opts, seq1, seq2)
+	printOut(func(withColor bool) string {
+		return formatRes(allg, res, seq1, seq2, withColor) + coOptimal(withColor)
+	})
+}
+
+// formatCoOptimal counts and enumerates co-optimal allignments of seq1 and seq2 if it is enabled
+func formatCoOptimal(allg sequence.Alligner, opts sequence.Options, seq1, seq2 *AminoSequence) func(withColor bool) string {
+	bld := strings.Builder{}
+	if countOptimal {
+		count, err := sequence.CountCoOptimal(allg, seq1.Value, seq2.Value, opts)
+		if err != nil {
+			fatal("counting co-optimal allignments %s", err.Error())
+		}
+		unique := "unique"
+		if count.Cmp(big.NewInt(1)) != 0 {
+			unique = "not unique"
+		}
+		bld.WriteString(fmt.Sprintf("co-optimal allignments: %s (%s)\n", count.String(), unique))
+	}
+	var res []*sequence.Allignment
+	if enumOptimal > 0 {
+		var err error
+		res, err = sequence.EnumerateCoOptimal(allg, seq1.Value, seq2.Value, enumOptimal, opts)
+		if err != nil {
+			fatal("enumerating co-optimal allignments %s", err.Error())
+		}
+	}
+	return func(withColor bool) string {
+		out := strings.Builder{}
+		out.WriteString(bld.String())
+		for k, r := range res {
+			out.WriteString(fmt.Sprintf("\nco-optimal allignment %d:\n", k+1))
+			out.WriteString(formatRes(allg, r, seq1, seq2, withColor))
+		}
+		return out.String()
+	}
+}
+
+func runLocalBest(allg sequence.Alligner, opts sequence.Options, seq1, seq2 *AminoSequence) {
+	if !local || kBest < 1 {
+		fatal("-k-best needs -local and positive amount of allignments")
 	}
 	t := time.Now()
-	res1, res2, v, err := allign(allg, seq1.Value, seq2.Value, amThreads)
+	res, err := sequence.AllignLocalBest(allg, seq1.Value, seq2.Value, kBest, opts)
 	if logTime {
 		log.Print("calculation time: ", time.Now().Sub(t))
 	}
 	if err != nil {
 		fatal("alligning %s", err.Error())
 	}
-	printRes(allg, res1, res2, int(v))
+	printOut(func(withColor bool) string {
+		bld := strings.Builder{}
+		for k, r := range res {
+			if k > 0 {
+				bld.WriteByte('\n')
+			}
+			bld.WriteString(fmt.Sprintf("allignment %d:\n", k+1))
+			bld.WriteString(formatRes(allg, r, seq1, seq2, withColor))
+		}
+		return bld.String()
+	})
 }
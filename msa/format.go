@@ -0,0 +1,121 @@
+package msa
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// lineWidth is amount of residues in a line of Clustal and FASTA output
+const lineWidth = 60
+
+// Clustal groups of residues with strong and weak similarity, used in conservation line
+var (
+	strongGroups = []string{"STA", "NEQK", "NHQK", "NDEQ", "QHRK", "MILV", "MILF", "HY", "FYW"}
+	weakGroups   = []string{"CSA", "ATV", "SAG", "STNK", "STPA", "SGND", "SNDEQK", "NDEQHK", "NEQHRK", "FVLIM", "HFY"}
+)
+
+// conservation returns Clustal conservation symbol of column c:
+// '*' for identical residues, ':' and '.' if all residues are in a strong or weak group
+func conservation(rows []string, c int, gap byte) byte {
+	residues := make([]byte, 0, len(rows))
+	for _, row := range rows {
+		if row[c] == gap {
+			return ' '
+		}
+		residues = append(residues, row[c])
+	}
+	identical := true
+	for _, r := range residues {
+		identical = identical && r == residues[0]
+	}
+	if identical {
+		return '*'
+	}
+	inGroup := func(groups []string) bool {
+		for _, g := range groups {
+			all := true
+			for _, r := range residues {
+				all = all && strings.IndexByte(g, r) >= 0
+			}
+			if all {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case inGroup(strongGroups):
+		return ':'
+	case inGroup(weakGroups):
+		return '.'
+	}
+	return ' '
+}
+
+func nameWidth(names []string) int {
+	w := 10
+	for _, n := range names {
+		if len(n)+1 > w {
+			w = len(n) + 1
+		}
+	}
+	return w
+}
+
+// WriteClustal writes allignment in Clustal format with conservation line
+func WriteClustal(w io.Writer, names, rows []string, gap byte) error {
+	bld := &strings.Builder{}
+	bld.WriteString("CLUSTAL W multiple sequence alignment\n\n")
+	width := nameWidth(names)
+	l := 0
+	if len(rows) > 0 {
+		l = len(rows[0])
+	}
+	for beg := 0; beg < l; beg += lineWidth {
+		end := beg + lineWidth
+		if end > l {
+			end = l
+		}
+		for i, row := range rows {
+			bld.WriteString(fmt.Sprintf("%-*s%s\n", width, names[i], row[beg:end]))
+		}
+		bld.WriteString(strings.Repeat(" ", width))
+		for c := beg; c < end; c++ {
+			bld.WriteByte(conservation(rows, c, gap))
+		}
+		bld.WriteString("\n\n")
+	}
+	_, err := io.WriteString(w, bld.String())
+	return err
+}
+
+// WriteFasta writes allignment as alligned FASTA
+func WriteFasta(w io.Writer, names, rows []string) error {
+	bld := &strings.Builder{}
+	for i, row := range rows {
+		bld.WriteString(">" + names[i] + "\n")
+		for beg := 0; beg < len(row); beg += lineWidth {
+			end := beg + lineWidth
+			if end > len(row) {
+				end = len(row)
+			}
+			bld.WriteString(row[beg:end] + "\n")
+		}
+	}
+	_, err := io.WriteString(w, bld.String())
+	return err
+}
+
+// WriteStockholm writes allignment in Stockholm format
+func WriteStockholm(w io.Writer, names, rows []string) error {
+	bld := &strings.Builder{}
+	bld.WriteString("# STOCKHOLM 1.0\n\n")
+	width := nameWidth(names)
+	for i, row := range rows {
+		bld.WriteString(fmt.Sprintf("%-*s%s\n", width, names[i], row))
+	}
+	bld.WriteString("//\n")
+	_, err := io.WriteString(w, bld.String())
+	return err
+}
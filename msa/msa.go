@@ -0,0 +1,129 @@
+// Package msa builds progressive multiple sequence allignments
+package msa
+
+import (
+	"math"
+
+	"lab2/sequence"
+	"lab2/tree"
+
+	"github.com/pkg/errors"
+)
+
+// Guide tree methods
+const (
+	TreeUPGMA = "upgma"
+	TreeNJ    = "nj"
+)
+
+// Options configures Allign
+type Options struct {
+	// Tree is guide tree method, UPGMA if empty
+	Tree string
+	// Threads is amount of workers for pairwise scores and threads of profile allignments
+	Threads   int
+	MemoryOpt bool
+}
+
+// Allign builds progressive multiple allignment of seqs:
+// pairwise scores give distances for guide tree, then profiles are alligned up the tree
+// with sum-of-pairs scoring and affine gap penalties of alg (see SumOfPairs).
+// Rows of the result are in the order of seqs.
+func Allign(alg sequence.Alligner, seqs []string, opts Options) ([]string, error) {
+	if opts.Tree != "" && opts.Tree != TreeUPGMA && opts.Tree != TreeNJ {
+		return nil, errors.Errorf("unknown tree method %q", opts.Tree)
+	}
+	if len(seqs) == 0 {
+		return nil, errors.New("no sequences")
+	}
+	for i, s := range seqs {
+		if len(s) == 0 {
+			return nil, errors.Errorf("sequence %d is empty", i+1)
+		}
+	}
+	if len(seqs) == 1 {
+		return []string{seqs[0]}, nil
+	}
+	root, err := GuideTree(alg, seqs, opts)
+	if err != nil {
+		return nil, err
+	}
+	return AllignWithTree(alg, seqs, root, opts)
+}
+
+// GuideTree builds tree from distances 1 - S(a, b) / min(S(a, a), S(b, b)) of pairwise scores
+func GuideTree(alg sequence.Alligner, seqs []string, opts Options) (*tree.Node, error) {
+	pm, err := sequence.AllVsAll(func(i, j int) sequence.Alligner {
+		return alg
+	}, seqs, sequence.Options{Threads: opts.Threads}, false)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(seqs))
+	dist := make([][]float64, len(seqs))
+	for i := range dist {
+		dist[i] = make([]float64, len(seqs))
+		for j := range dist[i] {
+			if i == j {
+				continue
+			}
+			norm := math.Min(pm.Scores[i][i], pm.Scores[j][j])
+			d := float64(1)
+			if norm > 0 {
+				d = math.Max(0, 1-pm.Scores[i][j]/norm)
+			}
+			dist[i][j] = d
+		}
+	}
+	if opts.Tree == TreeNJ {
+		return tree.NJ(names, dist)
+	}
+	return tree.UPGMA(names, dist)
+}
+
+// AllignWithTree alligns profiles of seqs up the guide tree, leaves of tree are indices of seqs
+func AllignWithTree(alg sequence.Alligner, seqs []string, root *tree.Node, opts Options) ([]string, error) {
+	dpOpts := sequence.Options{Threads: opts.Threads, MemoryOpt: opts.MemoryOpt}
+	profiles := make(map[*tree.Node]*profile)
+	var err error
+	root.PostOrder(func(n *tree.Node) {
+		if err != nil {
+			return
+		}
+		if n.IsLeaf() {
+			if n.Leaf < 0 || n.Leaf >= len(seqs) {
+				err = errors.Errorf("leaf %d is out of sequences", n.Leaf)
+				return
+			}
+			profiles[n] = newProfile(n.Leaf, seqs[n.Leaf])
+			return
+		}
+		p := profiles[n.Children[0]]
+		for _, c := range n.Children[1:] {
+			p, err = allignProfiles(alg, p, profiles[c], dpOpts)
+			if err != nil {
+				return
+			}
+		}
+		profiles[n] = p
+		for _, c := range n.Children {
+			delete(profiles, c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := profiles[root]
+	if len(p.seqs) != len(seqs) {
+		return nil, errors.Errorf("tree has %d leaves for %d sequences", len(p.seqs), len(seqs))
+	}
+	res := make([]string, len(seqs))
+	for r, idx := range p.seqs {
+		if res[idx] != "" {
+			return nil, errors.Errorf("sequence %d is in tree twice", idx+1)
+		}
+		res[idx] = p.rows[r]
+	}
+	return res, nil
+}
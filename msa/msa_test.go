@@ -0,0 +1,135 @@
+package msa
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"lab2/sequence"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireValidMSA(t *testing.T, seqs, rows []string) {
+	require.Len(t, rows, len(seqs))
+	for i, row := range rows {
+		require.Len(t, row, len(rows[0]))
+		require.Equal(t, seqs[i], strings.ReplaceAll(row, "-", ""))
+	}
+	for c := range rows[0] {
+		allGaps := true
+		for _, row := range rows {
+			allGaps = allGaps && row[c] == '-'
+		}
+		require.False(t, allGaps, "column %d has only gaps", c)
+	}
+}
+
+func TestAllign(t *testing.T) {
+	allg := sequence.NewAlligerBLOSUM62(-11, -1)
+	seqs := []string{
+		"MKTAYIAKQRQISFVKSHFSRQ",
+		"MKTAYIAKQRQISFVKSHFSRQ",
+		"MKTAYIAKQISFVKSHFSRQ",
+		"MKTAWIAKQRQISFVKSHFSRQLEE",
+	}
+	for _, tr := range []string{TreeUPGMA, TreeNJ} {
+		for _, memOpt := range []bool{false, true} {
+			rows, err := Allign(allg, seqs, Options{Tree: tr, Threads: 2, MemoryOpt: memOpt})
+			require.NoError(t, err)
+			requireValidMSA(t, seqs, rows)
+			require.Equal(t, []string{
+				"MKTAYIAKQRQISFVKSHFSRQ---",
+				"MKTAYIAKQRQISFVKSHFSRQ---",
+				"MKTAYIAKQ--ISFVKSHFSRQ---",
+				"MKTAWIAKQRQISFVKSHFSRQLEE",
+			}, rows, "tree %s, mem-opt %v", tr, memOpt)
+		}
+	}
+
+	rows, err := Allign(allg, seqs[:1], Options{})
+	require.NoError(t, err)
+	require.Equal(t, seqs[:1], rows)
+
+	_, err = Allign(allg, []string{"AAA", "AJA"}, Options{})
+	require.Error(t, err)
+	_, err = Allign(allg, seqs, Options{Tree: "bad"})
+	require.Error(t, err)
+}
+
+func TestAllignRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	allg := sequence.NewAlligerDNA(-10, -1)
+	anc := make([]byte, 80)
+	for i := range anc {
+		anc[i] = "ATGC"[r.Intn(4)]
+	}
+	seqs := make([]string, 8)
+	for i := range seqs {
+		s := make([]byte, 0, len(anc))
+		for _, b := range anc {
+			switch p := r.Float64(); {
+			case p < 0.05:
+			case p < 0.15:
+				s = append(s, "ATGC"[r.Intn(4)])
+			case p < 0.2:
+				s = append(s, b, "ATGC"[r.Intn(4)])
+			default:
+				s = append(s, b)
+			}
+		}
+		seqs[i] = string(s)
+	}
+	rows, err := Allign(allg, seqs, Options{Threads: 3})
+	require.NoError(t, err)
+	requireValidMSA(t, seqs, rows)
+}
+
+func TestFormats(t *testing.T) {
+	names := []string{"a", "seq_b"}
+	rows := []string{"ACD-K", "ACEIR"}
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteClustal(buf, names, rows, '-'))
+	require.Equal(t, "CLUSTAL W multiple sequence alignment\n\n"+
+		"a         ACD-K\n"+
+		"seq_b     ACEIR\n"+
+		"          **: :\n\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteFasta(buf, names, rows))
+	require.Equal(t, ">a\nACD-K\n>seq_b\nACEIR\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteStockholm(buf, names, rows))
+	require.Equal(t, "# STOCKHOLM 1.0\n\na         ACD-K\nseq_b     ACEIR\n//\n", buf.String())
+}
+
+func TestSumOfPairs(t *testing.T) {
+	allg := sequence.NewAlligerDNA(-10, -1)
+	// pairs: 5+5-10+5+5, 5-10+5-4 (gap-gap column skipped), 5-10-1+5-4
+	score, err := SumOfPairs(allg, []string{"AC-GT", "ACCGT", "A--GA"})
+	require.NoError(t, err)
+	require.Equal(t, float64(10-4-5), score)
+	// gaps in different rows are opened separately
+	score, err = SumOfPairs(allg, []string{"A-C", "AG-"})
+	require.NoError(t, err)
+	require.Equal(t, float64(5-10-10), score)
+
+	// the same as pairwise score for two sequences
+	for _, allg := range []sequence.Alligner{sequence.NewAlligerBLOSUM62(-11, -1), sequence.NewAlligerBLOSUM62(-4, -4)} {
+		seqs := []string{"MKTAYIAKQRQISFVKSHFSRQ", "MKTAWIAKQISFVKSHFSRQLEE"}
+		rows, err := Allign(allg, seqs, Options{Threads: 1})
+		require.NoError(t, err)
+		score, err := SumOfPairs(allg, rows)
+		require.NoError(t, err)
+		exp, err := sequence.AllignWithOptions(allg, seqs[0], seqs[1], sequence.Options{})
+		require.NoError(t, err)
+		require.Equal(t, exp.Score, score)
+	}
+
+	_, err = SumOfPairs(allg, []string{"AC", "A"})
+	require.Error(t, err)
+	_, err = SumOfPairs(allg, []string{"AC", "AJ"})
+	require.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package msa
+
+import "lab2/sequence"
+
+// profile is a group of alligned sequences
+type profile struct {
+	// seqs are indices of input sequences
+	seqs []int
+	rows []string
+}
+
+func newProfile(idx int, seq string) *profile {
+	return &profile{
+		seqs: []int{idx},
+		rows: []string{seq},
+	}
+}
+
+// allignProfiles alligns two profiles with sum-of-pairs scores and merges them
+func allignProfiles(alg sequence.Alligner, a, b *profile, opts sequence.Options) (*profile, error) {
+	pa, err := sequence.NewProfile(alg, a.rows)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := sequence.NewProfile(alg, b.rows)
+	if err != nil {
+		return nil, err
+	}
+	res, err := sequence.AllignProfiles(alg, pa, pb, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &profile{
+		seqs: append(append([]int(nil), a.seqs...), b.seqs...),
+		rows: make([]string, 0, len(a.rows)+len(b.rows)),
+	}
+	for _, row := range a.rows {
+		merged.rows = append(merged.rows, sequence.InsertGaps(alg, row, res.ResA))
+	}
+	for _, row := range b.rows {
+		merged.rows = append(merged.rows, sequence.InsertGaps(alg, row, res.ResB))
+	}
+	return merged, nil
+}
@@ -0,0 +1,69 @@
+package msa
+
+import (
+	"lab2/sequence"
+
+	"github.com/pkg/errors"
+)
+
+// gap states of a pair of rows
+const (
+	noGap = iota
+	gapA
+	gapB
+)
+
+// SumOfPairs is the sum of scores of pairwise allignments induced by rows of multiple allignment.
+// Columns with gaps in both rows of a pair are removed from it (natural gap convention), so every pair
+// is scored as AllignWithOptions scores the same pairwise allignment: gap open and extend of alg.
+// Profile DP of Allign scores pairs of columns by sum-of-pairs too (see sequence.Column), but gap open
+// of a pair is decided by the column alone, so the DP optimizes an approximation of SumOfPairs.
+func SumOfPairs(alg sequence.Alligner, rows []string) (float64, error) {
+	for r, row := range rows {
+		if len(row) != len(rows[0]) {
+			return 0, errors.Errorf("row %d has length %d, expected %d", r+1, len(row), len(rows[0]))
+		}
+		for i := 0; i < len(row); i++ {
+			if row[i] != alg.Gap() && !alg.InAlphabet(row[i]) {
+				return 0, errors.WithMessagef(
+					errors.Wrapf(sequence.ErrBadSeq, "symbol %q at position %d is not in alphabet", row[i], i+1),
+					"row %d", r+1)
+			}
+		}
+	}
+	score := float64(0)
+	for x := range rows {
+		for y := x + 1; y < len(rows); y++ {
+			score += pairScore(alg, rows[x], rows[y])
+		}
+	}
+	return score, nil
+}
+
+// pairScore scores allignment of rows a and b, columns of two gaps are skipped
+func pairScore(alg sequence.Alligner, a, b string) float64 {
+	gap := alg.Gap()
+	score := float64(0)
+	prev := noGap
+	for c := 0; c < len(a); c++ {
+		cur := noGap
+		switch {
+		case a[c] == gap && b[c] == gap:
+			continue
+		case a[c] == gap:
+			cur = gapA
+		case b[c] == gap:
+			cur = gapB
+		}
+		switch {
+		case cur == noGap:
+			score += alg.Compare(a[c], b[c])
+		case cur == prev && alg.IsExtended():
+			score += alg.GapExtend()
+		default:
+			score += alg.GapOpen()
+		}
+		prev = cur
+	}
+	return score
+}
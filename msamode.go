@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"lab2/msa"
+	"log"
+	"time"
+)
+
+const (
+	formatClustal   = "clustal"
+	formatFasta     = "fasta"
+	formatStockholm = "stockholm"
+)
+
+func runMSA(files []string) {
+	format := formatClustal
+	if isFlagPassed("format") {
+		format = outFormat
+	}
+	switch format {
+	case formatClustal, formatFasta, formatStockholm:
+	default:
+		fatal("unknown msa format %q", format)
+	}
+	if local || freeEnds != "" {
+		fatal("msa supports only global allignment")
+	}
+	seqs := readAllSeqs(files)
+	values := make([]string, len(seqs))
+	for i, seq := range seqs {
+		values[i] = seq.Value
+	}
+
+	t := time.Now()
+	allg := newAlligner()
+	rows, err := msa.Allign(allg, values, msa.Options{
+		Tree:      treeKind,
+		Threads:   amThreads,
+		MemoryOpt: memOpt,
+	})
+	if err != nil {
+		fatal("alligning %s", err)
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+		sp, err := msa.SumOfPairs(allg, rows)
+		if err != nil {
+			fatal("scoring allignment %s", err)
+		}
+		log.Printf("sum-of-pairs score: %g", sp)
+	}
+
+	buf := &bytes.Buffer{}
+	names := seqNames(seqs)
+	switch format {
+	case formatClustal:
+		err = msa.WriteClustal(buf, names, rows, allg.Gap())
+	case formatFasta:
+		err = msa.WriteFasta(buf, names, rows)
+	case formatStockholm:
+		err = msa.WriteStockholm(buf, names, rows)
+	}
+	if err != nil {
+		fatal("writing allignment %s", err)
+	}
+	printOut(func(bool) string {
+		return buf.String()
+	})
+}
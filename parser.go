@@ -11,7 +11,46 @@ import (
 type AminoSequence struct {
 	ID          string
 	Description string
-	Value       string
+	// Header is the original header line without '>'
+	Header string
+	// Value is upper case, lower case (soft-masked) residues are marked in Mask
+	Value string
+	// Mask marks soft-masked residues of Value, nil if there are none
+	Mask []bool
+	// Quality is Phred quality of every residue of FASTQ record, nil for FASTA
+	Quality []byte
+}
+
+// Masked reports if residue i is soft-masked
+func (s *AminoSequence) Masked(i int) bool {
+	return i < len(s.Mask) && s.Mask[i]
+}
+
+// Ungapped returns sequence with gaps of pre-alligned FASTA removed
+func (s *AminoSequence) Ungapped() *AminoSequence {
+	if strings.IndexByte(s.Value, '-') < 0 {
+		return s
+	}
+	res := &AminoSequence{
+		ID:          s.ID,
+		Description: s.Description,
+		Header:      s.Header,
+	}
+	valueBuilder := &strings.Builder{}
+	for i := 0; i < len(s.Value); i++ {
+		if s.Value[i] == '-' {
+			continue
+		}
+		valueBuilder.WriteByte(s.Value[i])
+		if s.Mask != nil {
+			res.Mask = append(res.Mask, s.Mask[i])
+		}
+		if s.Quality != nil {
+			res.Quality = append(res.Quality, s.Quality[i])
+		}
+	}
+	res.Value = valueBuilder.String()
+	return res
 }
 
 // Possible parse errors
@@ -22,16 +61,24 @@ var (
 
 // FastaParser parses a sequence of objects from reader
 type FastaParser struct {
-	reader *bufio.Reader
+	reader       *bufio.Reader
+	headerParser HeaderParser
 }
 
-// NewFastaParser returns new FastaParser
+// NewFastaParser returns new FastaParser, header style is detected by AutoHeaderParser
 func NewFastaParser(r io.Reader) *FastaParser {
 	return &FastaParser{
-		reader: bufio.NewReader(r),
+		reader:       bufio.NewReader(r),
+		headerParser: AutoHeaderParser,
 	}
 }
 
+// WithHeaderParser sets parser of header lines
+func (p *FastaParser) WithHeaderParser(hp HeaderParser) *FastaParser {
+	p.headerParser = hp
+	return p
+}
+
 // Next gets next object from reader.
 // Returns io.EOF if all objects were read.
 func (p *FastaParser) Next() (*AminoSequence, error) {
@@ -40,12 +87,13 @@ func (p *FastaParser) Next() (*AminoSequence, error) {
 		return nil, err
 	}
 
+	header = strings.TrimRight(header, "\r\n")
 	id, descr, err := p.parseHeader(header)
 	if err != nil {
 		return nil, err
 	}
 
-	valueBuilder := &strings.Builder{}
+	rb := &residueBuilder{}
 	for {
 		b, err := p.reader.ReadByte()
 		if err != nil {
@@ -63,33 +111,59 @@ func (p *FastaParser) Next() (*AminoSequence, error) {
 			break
 		}
 		// ignore newline
-		if b == '\n' {
+		if b == '\n' || b == '\r' {
 			continue
 		}
 
-		if b < 'A' || b > 'Z' {
-			return nil, ErrUnknownSymbol
+		if !rb.add(b) {
+			return nil, errors.Wrapf(ErrUnknownSymbol, "%q in %s", b, id)
 		}
-
-		valueBuilder.WriteByte(b)
 	}
 
 	return &AminoSequence{
 		ID:          id,
 		Description: descr,
-		Value:       valueBuilder.String(),
+		Header:      header[1:],
+		Value:       rb.value.String(),
+		Mask:        rb.mask,
 	}, nil
 }
 
+// residueBuilder collects residues of a sequence in upper case, lower case ones are marked as soft-masked
+type residueBuilder struct {
+	value strings.Builder
+	mask  []bool
+}
+
+// add returns false if b is not a residue symbol
+func (rb *residueBuilder) add(b byte) bool {
+	masked := false
+	switch {
+	case b >= 'A' && b <= 'Z', b == '*', b == '-':
+	case b >= 'a' && b <= 'z':
+		b = b - 'a' + 'A'
+		masked = true
+	default:
+		return false
+	}
+	if masked && rb.mask == nil {
+		rb.mask = make([]bool, rb.value.Len(), rb.value.Len()+1)
+	}
+	if rb.mask != nil {
+		rb.mask = append(rb.mask, masked)
+	}
+	rb.value.WriteByte(b)
+	return true
+}
+
 func (p *FastaParser) parseHeader(h string) (string, string, error) {
 	if len(h) == 0 || h[0] != '>' {
 		return "", "", ErrBadHeader
 	}
 
-	info := strings.Split(h[1:], "|")
-	if len(info) != 3 {
-		return "", "", ErrBadHeader
+	id, descr, err := p.headerParser.ParseHeader(h[1:])
+	if err != nil {
+		return "", "", errors.WithMessagef(err, "header %q", h)
 	}
-
-	return info[1], info[2], nil
+	return id, descr, nil
 }
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"lab2/kmer"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// indexKind returns kind of index for selected table
+func indexKind() kmer.Kind {
+	if isNucleotideType() {
+		return kmer.DNA
+	}
+	return kmer.Protein
+}
+
+// seedPattern returns -seed pattern or contiguous word of -word residues
+func seedPattern(kind kmer.Kind) string {
+	if seedMask != "" {
+		if isFlagPassed("word") {
+			fatal("-word can not be used with -seed")
+		}
+		return seedMask
+	}
+	w := wordSize
+	if w == 0 {
+		w = 3
+		if kind == kmer.DNA {
+			w = 11
+		}
+	}
+	if w < 0 {
+		fatal("bad -word %d", w)
+	}
+	return kmer.ContiguousSeed(w)
+}
+
+func runIndex(files []string) {
+	if outFile == "" {
+		fatal("index mode needs output file -o")
+	}
+	kind := indexKind()
+	seed := seedPattern(kind)
+
+	t := time.Now()
+	var records []kmer.Record
+	for _, file := range files {
+		err := forEachSeq(file, func(seq *AminoSequence) error {
+			seq = seq.Ungapped()
+			records = append(records, kmer.Record{ID: seq.ID, Header: seq.Header, Seq: seq.Value})
+			return nil
+		})
+		if err != nil {
+			fatal(err.Error())
+		}
+	}
+	ix, err := kmer.Build(kind, seed, records)
+	if err != nil {
+		fatal("building index: %s", err)
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "opening file "+outFile).Error())
+	}
+	if err := ix.Write(f); err != nil {
+		log.Fatal(errors.Wrap(err, "writing index "+outFile).Error())
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(errors.Wrap(err, "writing index "+outFile).Error())
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+	log.Printf("%s index of %d records, seed %s, %d words", kind, len(records), seed, ix.Words())
+}
+
+func readIndexFromFile(filename string) *kmer.Index {
+	f, err := openInput(filename)
+	if err != nil {
+		fatal(errors.Wrap(err, "opening file "+filename).Error())
+	}
+	defer f.Close()
+	ix, err := kmer.Read(f)
+	if err != nil {
+		fatal(errors.Wrap(err, "reading index "+filename).Error())
+	}
+	return ix
+}
+
+// searchOptions returns defaults of index kind overridden by passed flags
+func searchOptions(kind kmer.Kind) kmer.SearchOptions {
+	opts := kmer.DefaultSearchOptions(kind)
+	if isFlagPassed("neighbor") {
+		opts.Threshold = neighbor
+	}
+	if isFlagPassed("xdrop") {
+		opts.XDrop = xDrop
+	}
+	if isFlagPassed("xdrop-gapped") {
+		opts.XDropGapped = xDropGapped
+	}
+	if isFlagPassed("min-ungapped") {
+		opts.MinUngapped = minUngapped
+	}
+	if top < 0 {
+		fatal("-top must not be negative")
+	}
+	opts.Top = top
+	opts.Options = newOptions()
+	return opts
+}
+
+func runSearch(files []string) {
+	if len(files) != 2 {
+		fatal("search mode needs query and index files, got %d files", len(files))
+	}
+	if useQuality || maskWeight != 1 {
+		fatal("-quality and -mask-weight can not be used in search mode")
+	}
+	queries, err := readSeqsFromFile(files[0])
+	if err != nil {
+		fatal(err.Error())
+	}
+	if len(queries) != 1 {
+		fatal("query file should have 1 sequence, got %d", len(queries))
+	}
+	query := queries[0].Ungapped()
+	ix := readIndexFromFile(files[1])
+	if ix.Kind != indexKind() {
+		fatal("index of %s can not be searched with -type %s", ix.Kind, tableType)
+	}
+	allg := queryAlligner(newAlligner(), query)
+	opts := searchOptions(ix.Kind)
+	residues := 0
+	for _, rec := range ix.Records {
+		residues += len(rec.Seq)
+	}
+	initStats(residues)
+
+	t := time.Now()
+	hits, err := ix.Search(allg, query.Value, opts)
+	if err != nil {
+		fatal("search: %s", err)
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+
+	printOut(func(withColor bool) string {
+		bld := strings.Builder{}
+		bld.WriteString(fmt.Sprintf("query: %s\n", query.Header))
+		bld.WriteString(fmt.Sprintf("hits: %d of %d\n", len(hits), len(ix.Records)))
+		for i, hit := range hits {
+			rec := ix.Records[hit.Record]
+			score := hit.Allignment.Score
+			bld.WriteString(fmt.Sprintf("%d\t%g%s\t%g\t%s\n", i+1, score, statsColumns(score, len(query.Value)), hit.Extension.Score, rec.ID))
+		}
+		for i, hit := range hits {
+			rec := ix.Records[hit.Record]
+			seq := &AminoSequence{ID: rec.ID, Header: rec.Header, Value: rec.Seq}
+			bld.WriteString(fmt.Sprintf("\nhit %d: %s\n", i+1, rec.Header))
+			bld.WriteString(formatRes(allg, hit.Allignment, query, seq, withColor))
+		}
+		return bld.String()
+	})
+}
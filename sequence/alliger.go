@@ -1,6 +1,7 @@
 package sequence
 
 import (
+	"math"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -17,17 +18,46 @@ type Alligner interface {
 	Gap() byte
 }
 
+// PositionalAlligner is Alligner which scores depend on positions of residues in sequences,
+// i and j are 0-based positions of a in the first sequence and b in the second one.
+// All allignment functions use CompareAt instead of Compare for such Alligner.
+type PositionalAlligner interface {
+	Alligner
+	CompareAt(i int, a byte, j int, b byte) float64
+}
+
+type compareFunc func(i int, a byte, j int, b byte) float64
+
+func newCompareFunc(alg Alligner) compareFunc {
+	if p, ok := alg.(PositionalAlligner); ok {
+		return p.CompareAt
+	}
+	return func(_ int, a byte, _ int, b byte) float64 {
+		return alg.Compare(a, b)
+	}
+}
+
 type AllignerImpl struct {
 	alg Alligner
 }
 
-func checkSeq(alg Alligner, a string) bool {
-	for _, v := range a {
-		if !alg.InAlphabet(byte(v)) || byte(v) == alg.Gap() {
-			return false
+func checkSeq(alg Alligner, a string) error {
+	for i := 0; i < len(a); i++ {
+		if !alg.InAlphabet(a[i]) || a[i] == alg.Gap() {
+			return errors.Wrapf(ErrBadSeq, "symbol %q at position %d is not in alphabet", a[i], i+1)
 		}
 	}
-	return true
+	return nil
+}
+
+func checkSeqs(alg Alligner, a, b string) error {
+	if err := checkSeq(alg, a); err != nil {
+		return errors.WithMessage(err, "seq a")
+	}
+	if err := checkSeq(alg, b); err != nil {
+		return errors.WithMessage(err, "seq b")
+	}
+	return nil
 }
 
 type allgAction int
@@ -45,6 +75,9 @@ const (
 	shiftMat = 0
 	shiftIns = 2
 	shiftDel = 4
+
+	// actionStop marks start of local alignment in both linear and extended tables
+	actionStop = allgAction(0)
 )
 
 type allgDinTable struct {
@@ -52,11 +85,15 @@ type allgDinTable struct {
 	vals [][]float64
 	inss [][]float64
 	dels [][]float64
+	gaps gapModel
+	cmp  compareFunc
+	band *band
 
 	calcImpl func(alg Alligner, i, j int, a, b byte)
 }
 
-func initDinTable(alg Alligner, a, b string) allgDinTable {
+func initDinTable(alg Alligner, a, b string, opts Options) allgDinTable {
+	gaps := newGapModel(alg, a, b, opts)
 	vals := make([][]float64, len(a)+1)
 	acts := make([][]allgAction, len(a)+1)
 	for i := 0; i <= len(a); i++ {
@@ -65,17 +102,19 @@ func initDinTable(alg Alligner, a, b string) allgDinTable {
 	}
 	vals[0][0] = 0
 	for i := 1; i <= len(a); i++ {
-		vals[i][0] = vals[i-1][0] + alg.GapOpen()
+		vals[i][0] = vals[i-1][0] + gaps.delOpen(0)
 		acts[i][0] = actionUp
 	}
 	for i := 1; i <= len(b); i++ {
-		vals[0][i] = vals[0][i-1] + alg.GapOpen()
+		vals[0][i] = vals[0][i-1] + gaps.insOpen(0)
 		acts[0][i] = actionLeft
 	}
 
 	dt := allgDinTable{
 		acts: acts,
 		vals: vals,
+		gaps: gaps,
+		cmp:  newCompareFunc(alg),
 	}
 	dt.calcImpl = dt.calc
 	return dt
@@ -84,9 +123,9 @@ func initDinTable(alg Alligner, a, b string) allgDinTable {
 func (dt *allgDinTable) calc(alg Alligner, i, j int, a, b byte) {
 	dt.vals[i][j], dt.acts[i][j] =
 		maxFloat3Dir(
-			dt.vals[i-1][j]+alg.GapOpen(),
-			dt.vals[i][j-1]+alg.GapOpen(),
-			dt.vals[i-1][j-1]+alg.Compare(a, b))
+			dt.vals[i-1][j]+dt.gaps.delOpen(j),
+			dt.vals[i][j-1]+dt.gaps.insOpen(i),
+			dt.vals[i-1][j-1]+dt.cmp(i-1, a, j-1, b))
 }
 
 func reverse(s string) string {
@@ -132,19 +171,21 @@ func (dt *allgDinTable) initExtend(alg Alligner, a, b string) {
 	dt.inss = inss
 	dt.dels = dels
 
-	inf := 2*alg.GapOpen() + float64(len(a)+len(b))*alg.GapExtend() - 10000
+	inf := dt.gaps.inf()
 	dt.vals[0][0] = 0
 	dt.inss[0][0] = inf
 	dt.dels[0][0] = inf
+	delOpen, delExt := dt.gaps.del(0)
 	for i := 1; i <= len(a); i++ {
 		dt.vals[i][0] = inf
 		dt.inss[i][0] = inf
-		dt.dels[i][0] = alg.GapOpen() + float64(i-1)*alg.GapExtend()
+		dt.dels[i][0] = delOpen + float64(i-1)*delExt
 		dt.acts[i][0] = dirDel << shiftDel
 	}
+	insOpen, insExt := dt.gaps.ins(0)
 	for i := 1; i <= len(b); i++ {
 		dt.vals[0][i] = inf
-		dt.inss[0][i] = alg.GapOpen() + float64(i-1)*alg.GapExtend()
+		dt.inss[0][i] = insOpen + float64(i-1)*insExt
 		dt.dels[0][i] = inf
 		dt.acts[0][i] = dirIns << shiftIns
 	}
@@ -153,9 +194,9 @@ func (dt *allgDinTable) initExtend(alg Alligner, a, b string) {
 }
 
 func (dt *allgDinTable) calcExtend(alg Alligner, i, j int, a, b byte) {
-	cmp := alg.Compare(a, b)
-	open := alg.GapOpen()
-	ext := alg.GapExtend()
+	cmp := dt.cmp(i-1, a, j-1, b)
+	insOpen, insExt := dt.gaps.ins(i)
+	delOpen, delExt := dt.gaps.del(j)
 
 	var actSt, actIns, actDel allgAction
 	dt.vals[i][j], actSt = maxFloat3DirAlt(
@@ -164,14 +205,14 @@ func (dt *allgDinTable) calcExtend(alg Alligner, i, j int, a, b byte) {
 		dt.dels[i-1][j-1]+cmp, dirDel,
 	)
 	dt.inss[i][j], actIns = maxFloat3DirAlt(
-		dt.vals[i][j-1]+open, dirMat,
-		dt.inss[i][j-1]+ext, dirIns,
-		dt.dels[i][j-1]+open, dirDel,
+		dt.vals[i][j-1]+insOpen, dirMat,
+		dt.inss[i][j-1]+insExt, dirIns,
+		dt.dels[i][j-1]+insOpen, dirDel,
 	)
 	dt.dels[i][j], actDel = maxFloat3DirAlt(
-		dt.vals[i-1][j]+open, dirMat,
-		dt.inss[i-1][j]+open, dirIns,
-		dt.dels[i-1][j]+ext, dirDel,
+		dt.vals[i-1][j]+delOpen, dirMat,
+		dt.inss[i-1][j]+delOpen, dirIns,
+		dt.dels[i-1][j]+delExt, dirDel,
 	)
 	dt.acts[i][j] = (actSt << shiftMat) | (actIns << shiftIns) | (actDel << shiftDel)
 }
@@ -241,7 +282,8 @@ func (dt *allgDinTable) calcRow(
 				break
 			}
 		}
-		for j := beg; j < end; j++ {
+		from, to := dt.band.cols(i, len(b))
+		for j := maxInt(beg, from); j < minInt(end, to+1); j++ {
 			dt.calcImpl(alg, i, j, a[i-1], b[j-1])
 		}
 		atomic.AddInt32(rBound, 1)
@@ -277,7 +319,66 @@ func (dt *allgDinTable) calcTable(alg Alligner, a, b string, amThreads int) {
 	wg.Wait()
 }
 
+// Mode selects the kind of alignment computed by AllignWithOptions
+type Mode int
+
+const (
+	// ModeGlobal aligns sequences end to end (Needleman-Wunsch)
+	ModeGlobal Mode = iota
+	// ModeLocal aligns the best scoring regions of sequences (Smith-Waterman)
+	ModeLocal
+	// ModeSemiGlobal is global allignment with end gaps selected by Options.FreeEnds not penalized
+	ModeSemiGlobal
+)
+
+// FreeEnds selects ends of sequences that may be left unaligned at no cost,
+// e.g. StartA allows leading residues of a to be aligned against gaps for free.
+type FreeEnds struct {
+	StartA bool
+	EndA   bool
+	StartB bool
+	EndB   bool
+}
+
+// Options configures AllignWithOptions
+type Options struct {
+	Mode      Mode
+	FreeEnds  FreeEnds
+	Threads   int
+	MemoryOpt bool
+	// GapsA and GapsB are position-specific gap penalties of a and b, nil means penalties of Alligner
+	GapsA *PositionGaps
+	GapsB *PositionGaps
+	// TieBreak selects allignment among co-optimal ones, not supported with MemoryOpt
+	TieBreak TieBreak
+	// Band is initial width of band around diagonals of table, only cells inside of it are calculated.
+	// It is doubled until the best allignment is provably inside of band, 0 means the whole table.
+	// Supported in global and semi-global modes, ignored by TieBreak.
+	Band int
+}
+
+// Allignment is a result of alignment.
+// Aligned regions of input sequences are a[BegA:EndA] and b[BegB:EndB].
+type Allignment struct {
+	ResA  string
+	ResB  string
+	Score float64
+	BegA  int
+	EndA  int
+	BegB  int
+	EndB  int
+}
+
 func Allign(alg Alligner, a, b string, amThreads int) (resA, resB string, v float64, err error) {
+	res, err := AllignWithOptions(alg, a, b, Options{Threads: amThreads})
+	if err != nil {
+		return "", "", 0, err
+	}
+	return res.ResA, res.ResB, res.Score, nil
+}
+
+// AllignWithOptions alligns a and b in mode set by opts
+func AllignWithOptions(alg Alligner, a, b string, opts Options) (res *Allignment, err error) {
 	defer func() {
 		if p, ok := recover().(int); ok {
 			if p == SwitchErr {
@@ -286,16 +387,107 @@ func Allign(alg Alligner, a, b string, amThreads int) (resA, resB string, v floa
 		}
 	}()
 
-	if !checkSeq(alg, a) || !checkSeq(alg, b) {
-		return "", "", 0, errors.New("bad seq")
+	if err := checkSeqs(alg, a, b); err != nil {
+		return nil, err
+	}
+	if err := checkGaps(opts, a, b); err != nil {
+		return nil, err
 	}
-	dt := initDinTable(alg, a, b)
-	allign := dt.allign
+	if err := checkBand(opts); err != nil {
+		return nil, err
+	}
+	if opts.Threads <= 0 {
+		opts.Threads = 1
+	}
+	var bd *band
+	if opts.Band > 0 && opts.TieBreak == TieDefault {
+		bd, _ = bandedScore(alg, a, b, opts)
+	}
+	if opts.MemoryOpt {
+		if opts.Mode == ModeLocal {
+			return nil, errors.New("local mode is not supported with memory optimization")
+		}
+		if opts.TieBreak != TieDefault {
+			return nil, errors.New("tie-break policy is not supported with memory optimization")
+		}
+		resA, resB, v := allignMemoryOpt(alg, a, b, opts, bd)
+		return &Allignment{ResA: resA, ResB: resB, Score: v, EndA: len(a), EndB: len(b)}, nil
+	}
+
+	switch opts.Mode {
+	case ModeGlobal, ModeSemiGlobal, ModeLocal:
+	default:
+		return nil, errors.Errorf("unknown mode %d", opts.Mode)
+	}
+	if opts.TieBreak != TieDefault {
+		return newCoTable(alg, a, b, opts).allign(opts.TieBreak), nil
+	}
+
+	dt := fillTable(alg, a, b, opts, bd)
+	if opts.Mode != ModeLocal {
+		allign := dt.allign
+		if alg.IsExtended() {
+			allign = dt.allignExtend
+		}
+		resA, resB, v := allign(alg, a, b)
+		return &Allignment{ResA: resA, ResB: resB, Score: v, EndA: len(a), EndB: len(b)}, nil
+	}
+	allign := dt.allignLocal
 	if alg.IsExtended() {
+		allign = dt.allignLocalExtend
+	}
+	endA, endB := dt.maxCell()
+	resA, resB, begA, begB := allign(alg, a, b, endA, endB)
+	return &Allignment{
+		ResA:  resA,
+		ResB:  resB,
+		Score: dt.vals[endA][endB],
+		BegA:  begA,
+		EndA:  endA,
+		BegB:  begB,
+		EndB:  endB,
+	}, nil
+}
+
+// fillTable calculates table of a and b in mode of opts, only cells inside of band if it is not nil
+func fillTable(alg Alligner, a, b string, opts Options, bd *band) allgDinTable {
+	dt := initDinTable(alg, a, b, opts)
+	switch {
+	case opts.Mode == ModeLocal && alg.IsExtended():
+		dt.initLocalExtend(alg, a, b)
+	case opts.Mode == ModeLocal:
+		dt.initLocal(alg, a, b)
+	case alg.IsExtended():
 		dt.initExtend(alg, a, b)
-		allign = dt.allignExtend
 	}
-	dt.calcTable(alg, a, b, amThreads)
-	resA, resB, v = allign(alg, a, b)
-	return resA, resB, v, nil
+	if bd != nil {
+		dt.initBand(a, b, bd)
+	}
+	dt.calcTable(alg, a, b, opts.Threads)
+	return dt
+}
+
+// initBand limits calculation to band, cells around it are unreachable
+func (dt *allgDinTable) initBand(a, b string, bd *band) {
+	dt.band = bd
+	inf := math.Inf(-1)
+	set := func(i, j int) {
+		if j < 0 || j > len(b) || !bd.out(i, j) {
+			return
+		}
+		dt.vals[i][j] = inf
+		if dt.inss != nil {
+			dt.inss[i][j] = inf
+			dt.dels[i][j] = inf
+		}
+	}
+	for j := 1; j <= len(b); j++ {
+		set(0, j)
+	}
+	for i := 1; i <= len(a); i++ {
+		from, to := bd.cols(i, len(b))
+		set(i, 0)
+		set(i, from-1)
+		set(i, to+1)
+	}
 }
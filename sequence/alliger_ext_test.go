@@ -1,6 +1,11 @@
 package sequence
 
-import "testing"
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func testAllignerExt() Alligner {
 	return &tableAlliger{
@@ -161,3 +166,40 @@ func TestAllgDNAExt(t *testing.T) {
 	}
 	runTestCases(t, allg, tcs)
 }
+
+func randomSeq(r *rand.Rand, alphabet string, l int) string {
+	res := make([]byte, l)
+	for i := range res {
+		res[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(res)
+}
+
+func TestAllgExtMemOptRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tcs := []struct {
+		allg     Alligner
+		alphabet string
+	}{
+		{NewAlligerBLOSUM62(-10, -1), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerBLOSUM62(-5, -2), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerDNA(-10, -1), "ATGC"},
+	}
+	for _, tc := range tcs {
+		for n := 0; n < 50; n++ {
+			a := randomSeq(r, tc.alphabet, r.Intn(40))
+			b := randomSeq(r, tc.alphabet, r.Intn(40))
+			ends := FreeEnds{StartA: r.Intn(2) == 0, EndA: r.Intn(2) == 0, StartB: r.Intn(2) == 0, EndB: r.Intn(2) == 0}
+			opts := Options{Mode: ModeSemiGlobal, FreeEnds: ends}
+			full, err := AllignWithOptions(tc.allg, a, b, opts)
+			require.NoError(t, err)
+			for _, threads := range []int{1, 2} {
+				opts := Options{Mode: ModeSemiGlobal, FreeEnds: ends, Threads: threads, MemoryOpt: true}
+				mem, err := AllignWithOptions(tc.allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, full.Score, mem.Score, "%s\n%s\n%+v", a, b, ends)
+				require.Equal(t, mem.Score, checkScoreEnds(tc.allg, mem.ResA, mem.ResB, ends))
+			}
+		}
+	}
+}
@@ -71,19 +71,17 @@ func runTestCases(t *testing.T, allg Alligner, tcs []testCaseAllg) {
 		require.Equal(t, tc.resB, resB, "failed seq B test %d", i)
 		require.Equal(t, tc.score, score, "failed SCORE test %d", i)
 	}
-	if !allg.IsExtended() {
-		for i, tc := range tcs {
-			resA, resB, score, err := AllignMemoryOpt(allg, tc.a, tc.b, 1)
-			require.NoError(t, err)
-			require.Equal(t, score, checkScore(allg, resA, resB), "incosistent result on\n%s\n%s", resA, resB)
-			require.Equal(t, tc.score, score, "failed SCORE test %d", i)
-		}
-		for i, tc := range tcs {
-			resA, resB, score, err := AllignMemoryOpt(allg, tc.a, tc.b, 8)
-			require.NoError(t, err)
-			require.Equal(t, score, checkScore(allg, resA, resB), "incosistent result on\n%s\n%s", resA, resB)
-			require.Equal(t, tc.score, score, "failed SCORE test %d", i)
-		}
+	for i, tc := range tcs {
+		resA, resB, score, err := AllignMemoryOpt(allg, tc.a, tc.b, 1)
+		require.NoError(t, err)
+		require.Equal(t, score, checkScore(allg, resA, resB), "incosistent result on\n%s\n%s", resA, resB)
+		require.Equal(t, tc.score, score, "failed SCORE test %d", i)
+	}
+	for i, tc := range tcs {
+		resA, resB, score, err := AllignMemoryOpt(allg, tc.a, tc.b, 8)
+		require.NoError(t, err)
+		require.Equal(t, score, checkScore(allg, resA, resB), "incosistent result on\n%s\n%s", resA, resB)
+		require.Equal(t, tc.score, score, "failed SCORE test %d", i)
 	}
 }
 
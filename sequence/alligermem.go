@@ -1,10 +1,9 @@
 package sequence
 
 import (
+	"math"
 	"strings"
 	"sync"
-
-	"github.com/pkg/errors"
 )
 
 type cell struct {
@@ -14,45 +13,62 @@ type cell struct {
 
 type allgDinTableMem struct {
 	alg     Alligner
+	gaps    gapModel
+	cmp     compareFunc
+	band    *band
 	a       string
 	b       string
 	upBuf   []float64
 	downBuf []float64
+	upExt   memExtBufs
+	downExt memExtBufs
 	resBuf  []allgAction
 	async   bool
 	wg      sync.WaitGroup
 }
 
-func initDinTableMem(alg Alligner, a, b string, amThreads int) allgDinTableMem {
-	if amThreads <= 0 {
-		amThreads = 1
+func initDinTableMem(alg Alligner, a, b string, opts Options) *allgDinTableMem {
+	dt := &allgDinTableMem{
+		alg:    alg,
+		gaps:   newGapModel(alg, a, b, opts),
+		cmp:    newCompareFunc(alg),
+		a:      a,
+		b:      b,
+		resBuf: make([]allgAction, len(a)+len(b)),
+		async:  opts.Threads > 1,
 	}
-	return allgDinTableMem{
-		alg:     alg,
-		a:       a,
-		b:       b,
-		upBuf:   make([]float64, len(a)+1),
-		downBuf: make([]float64, len(a)+1),
-		resBuf:  make([]allgAction, len(a)+len(b)),
-		async:   amThreads > 1,
+	if alg.IsExtended() {
+		dt.upExt = newMemExtBufs(len(a) + 1)
+		dt.downExt = newMemExtBufs(len(a) + 1)
+	} else {
+		dt.upBuf = make([]float64, len(a)+1)
+		dt.downBuf = make([]float64, len(a)+1)
 	}
+	return dt
 }
 
 func (dt *allgDinTableMem) calcFromUp(from, to cell, upBuf []float64) {
 	cur := float64(0)
 	for i := from.i; i <= to.i; i++ {
-		upBuf[i] = cur
-		cur += dt.alg.GapOpen()
+		upBuf[i] = dt.mask(i, from.j, cur)
+		cur += dt.gaps.delOpen(from.j)
 	}
 
+	// cells outside of band stay -Inf, only rows of band are calculated
 	var hold float64
 	for j := from.j; j < to.j; j++ {
-		hold, upBuf[from.i] = upBuf[from.i], upBuf[from.i]+dt.alg.GapOpen()
-		for i := from.i + 1; i <= to.i; i++ {
+		lo, hi := dt.band.rows(j+1, from.i, to.i)
+		if lo == from.i {
+			hold, upBuf[from.i] = upBuf[from.i], dt.mask(from.i, j+1, upBuf[from.i]+dt.gaps.insOpen(from.i))
+			lo++
+		} else {
+			hold, upBuf[lo-1] = upBuf[lo-1], math.Inf(-1)
+		}
+		for i := lo; i <= hi; i++ {
 			hold, upBuf[i] = upBuf[i], maxFloat3(
-				upBuf[i]+dt.alg.GapOpen(),
-				upBuf[i-1]+dt.alg.GapOpen(),
-				hold+dt.alg.Compare(dt.a[i-1], dt.b[j]),
+				upBuf[i]+dt.gaps.insOpen(i),
+				upBuf[i-1]+dt.gaps.delOpen(j+1),
+				hold+dt.cmp(i-1, dt.a[i-1], j, dt.b[j]),
 			)
 		}
 	}
@@ -61,23 +77,37 @@ func (dt *allgDinTableMem) calcFromUp(from, to cell, upBuf []float64) {
 func (dt *allgDinTableMem) calcFromDown(from, to cell, downBuf []float64) {
 	cur := float64(0)
 	for i := to.i; i >= from.i; i-- {
-		downBuf[i] = cur
-		cur += dt.alg.GapOpen()
+		downBuf[i] = dt.mask(i, to.j, cur)
+		cur += dt.gaps.delOpen(to.j)
 	}
 
 	var hold float64
 	for j := to.j; j > from.j; j-- {
-		hold, downBuf[to.i] = downBuf[to.i], downBuf[to.i]+dt.alg.GapOpen()
-		for i := to.i - 1; i >= from.i; i-- {
+		lo, hi := dt.band.rows(j-1, from.i, to.i)
+		if hi == to.i {
+			hold, downBuf[to.i] = downBuf[to.i], dt.mask(to.i, j-1, downBuf[to.i]+dt.gaps.insOpen(to.i))
+			hi--
+		} else {
+			hold, downBuf[hi+1] = downBuf[hi+1], math.Inf(-1)
+		}
+		for i := hi; i >= lo; i-- {
 			hold, downBuf[i] = downBuf[i], maxFloat3(
-				downBuf[i]+dt.alg.GapOpen(),
-				downBuf[i+1]+dt.alg.GapOpen(),
-				hold+dt.alg.Compare(dt.a[i], dt.b[j-1]),
+				downBuf[i]+dt.gaps.insOpen(i),
+				downBuf[i+1]+dt.gaps.delOpen(j-1),
+				hold+dt.cmp(i, dt.a[i], j-1, dt.b[j-1]),
 			)
 		}
 	}
 }
 
+// mask returns v for cell inside of band and -Inf for cell outside of it
+func (dt *allgDinTableMem) mask(i, j int, v float64) float64 {
+	if dt.band.out(i, j) {
+		return math.Inf(-1)
+	}
+	return v
+}
+
 func (dt *allgDinTableMem) calcFromUpDownAsync(upFrom, upTo, downFrom, downTo cell, upBuf, downBuf []float64) {
 	dt.wg.Add(2)
 	go func() {
@@ -142,10 +172,10 @@ func (dt *allgDinTableMem) calcPart(from, to cell) []allgAction {
 	upI := from.i
 	j := from.j + sizeFromUp
 	action := actionUp
-	val := dt.upBuf[upI] + dt.downBuf[upI] + dt.alg.GapOpen()
+	val := dt.upBuf[upI] + dt.downBuf[upI] + dt.gaps.insOpen(upI)
 	// actionUp check
 	for i := from.i; i <= to.i; i++ {
-		curVal := dt.upBuf[i] + dt.downBuf[i] + dt.alg.GapOpen()
+		curVal := dt.upBuf[i] + dt.downBuf[i] + dt.gaps.insOpen(i)
 
 		if curVal > val {
 			upI = i
@@ -154,7 +184,7 @@ func (dt *allgDinTableMem) calcPart(from, to cell) []allgAction {
 	}
 	// actionUpLeft check
 	for i := from.i; i < to.i; i++ {
-		curVal := dt.upBuf[i] + dt.downBuf[i+1] + dt.alg.Compare(dt.a[i], dt.b[j])
+		curVal := dt.upBuf[i] + dt.downBuf[i+1] + dt.cmp(i, dt.a[i], j, dt.b[j])
 
 		if curVal > val {
 			upI = i
@@ -183,7 +213,7 @@ func (dt *allgDinTableMem) calcPart(from, to cell) []allgAction {
 	return res
 }
 
-func (dt allgDinTableMem) allign(path []allgAction) (string, string, float64) {
+func (dt *allgDinTableMem) allign(path []allgAction) (string, string, float64) {
 
 	resA := strings.Builder{}
 	resB := strings.Builder{}
@@ -191,35 +221,65 @@ func (dt allgDinTableMem) allign(path []allgAction) (string, string, float64) {
 	resB.Grow(len(path))
 	val := float64(0)
 	i, j := 0, 0
+	prev := actionUpLeft
 	for c := 0; i < len(dt.a) || j < len(dt.b); c++ {
 		switch path[c] {
 		case actionUp:
 			resA.WriteByte(dt.alg.Gap())
 			resB.WriteByte(dt.b[j])
+			open, ext := dt.gaps.ins(i)
+			if dt.alg.IsExtended() && prev == actionUp {
+				open = ext
+			}
+			val += open
 			j++
-			val += dt.alg.GapOpen()
 		case actionLeft:
 			resA.WriteByte(dt.a[i])
 			resB.WriteByte(dt.alg.Gap())
+			open, ext := dt.gaps.del(j)
+			if dt.alg.IsExtended() && prev == actionLeft {
+				open = ext
+			}
+			val += open
 			i++
-			val += dt.alg.GapOpen()
 		case actionUpLeft:
 			resA.WriteByte(dt.a[i])
 			resB.WriteByte(dt.b[j])
-			val += dt.alg.Compare(dt.a[i], dt.b[j])
+			val += dt.cmp(i, dt.a[i], j, dt.b[j])
 			i++
 			j++
 		}
+		prev = path[c]
 	}
 	return resA.String(), resB.String(), val
 }
 
 func AllignMemoryOpt(alg Alligner, a, b string, amThreads int) (string, string, float64, error) {
-	if !checkSeq(alg, a) || !checkSeq(alg, b) {
-		return "", "", 0, errors.New("bad seq")
+	if err := checkSeqs(alg, a, b); err != nil {
+		return "", "", 0, err
 	}
+	resA, resB, v := allignMemoryOpt(alg, a, b, Options{Threads: amThreads}, nil)
+	return resA, resB, v, nil
+}
 
-	dt := initDinTableMem(alg, a, b, amThreads)
+func allignMemoryOpt(alg Alligner, a, b string, opts Options, bd *band) (string, string, float64) {
+	dt := initDinTableMem(alg, a, b, opts)
+	dt.band = bd
+	if alg.IsExtended() {
+		path := dt.calcPartExt(
+			cell{
+				i: 0,
+				j: 0,
+			},
+			cell{
+				i: len(a),
+				j: len(b),
+			},
+			dirMat,
+			stateAny,
+		)
+		return dt.allign(path)
+	}
 	path := dt.calcPart(
 		cell{
 			i: 0,
@@ -230,6 +290,5 @@ func AllignMemoryOpt(alg Alligner, a, b string, amThreads int) (string, string,
 			j: len(b),
 		},
 	)
-	resA, resB, v := dt.allign(path)
-	return resA, resB, v, nil
+	return dt.allign(path)
 }
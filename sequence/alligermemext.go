@@ -0,0 +1,222 @@
+package sequence
+
+import "math"
+
+// stateAny allows allignment part to end in any state
+const stateAny = allgAction(0)
+
+// memExtBufs are match, insertion and deletion states of one column
+type memExtBufs struct {
+	mat []float64
+	ins []float64
+	del []float64
+}
+
+func newMemExtBufs(l int) memExtBufs {
+	return memExtBufs{
+		mat: make([]float64, l),
+		ins: make([]float64, l),
+		del: make([]float64, l),
+	}
+}
+
+// calcFromUpExt calculates best scores of paths from `from` to cells of column to.j.
+// Path starts as if previous move was `start`, so it's gap of the same kind is extended.
+func (dt *allgDinTableMem) calcFromUpExt(from, to cell, start allgAction, up memExtBufs) {
+	inf := math.Inf(-1)
+	up.mat[from.i], up.ins[from.i], up.del[from.i] = inf, inf, inf
+	switch start {
+	case dirMat:
+		up.mat[from.i] = 0
+	case dirIns:
+		up.ins[from.i] = 0
+	case dirDel:
+		up.del[from.i] = 0
+	}
+	delOpen, delExt := dt.gaps.del(from.j)
+	for i := from.i + 1; i <= to.i; i++ {
+		up.mat[i], up.ins[i] = inf, inf
+		up.del[i] = dt.mask(i, from.j, maxFloat3(up.mat[i-1]+delOpen, up.ins[i-1]+delOpen, up.del[i-1]+delExt))
+	}
+
+	// cells outside of band stay -Inf, only rows of band are calculated
+	var holdMat, holdIns, holdDel float64
+	for j := from.j; j < to.j; j++ {
+		delOpen, delExt = dt.gaps.del(j + 1)
+		lo, hi := dt.band.rows(j+1, from.i, to.i)
+		if lo == from.i {
+			insOpen, insExt := dt.gaps.ins(from.i)
+			holdMat, holdIns, holdDel = up.mat[from.i], up.ins[from.i], up.del[from.i]
+			up.mat[from.i], up.del[from.i] = inf, inf
+			up.ins[from.i] = dt.mask(from.i, j+1, maxFloat3(holdMat+insOpen, holdIns+insExt, holdDel+insOpen))
+			lo++
+		} else {
+			holdMat, holdIns, holdDel = up.mat[lo-1], up.ins[lo-1], up.del[lo-1]
+			up.mat[lo-1], up.ins[lo-1], up.del[lo-1] = inf, inf, inf
+		}
+		for i := lo; i <= hi; i++ {
+			insOpen, insExt := dt.gaps.ins(i)
+			mat := maxFloat3(holdMat, holdIns, holdDel) + dt.cmp(i-1, dt.a[i-1], j, dt.b[j])
+			ins := maxFloat3(up.mat[i]+insOpen, up.ins[i]+insExt, up.del[i]+insOpen)
+			del := maxFloat3(up.mat[i-1]+delOpen, up.ins[i-1]+delOpen, up.del[i-1]+delExt)
+			holdMat, holdIns, holdDel = up.mat[i], up.ins[i], up.del[i]
+			up.mat[i], up.ins[i], up.del[i] = mat, ins, del
+		}
+	}
+}
+
+// calcFromDownExt calculates best scores of paths from cells of column from.j to `to`.
+// State of the cell is the kind of move that led into it, path must reach `to` in state `end`.
+func (dt *allgDinTableMem) calcFromDownExt(from, to cell, end allgAction, down memExtBufs) {
+	inf := math.Inf(-1)
+	down.mat[to.i], down.ins[to.i], down.del[to.i] = inf, inf, inf
+	if end == stateAny || end == dirMat {
+		down.mat[to.i] = 0
+	}
+	if end == stateAny || end == dirIns {
+		down.ins[to.i] = 0
+	}
+	if end == stateAny || end == dirDel {
+		down.del[to.i] = 0
+	}
+	delOpen, delExt := dt.gaps.del(to.j)
+	for i := to.i - 1; i >= from.i; i-- {
+		next := dt.mask(i, to.j, down.del[i+1])
+		down.mat[i], down.ins[i], down.del[i] = next+delOpen, next+delOpen, next+delExt
+	}
+
+	var holdMat float64
+	for j := to.j; j > from.j; j-- {
+		delOpen, delExt = dt.gaps.del(j - 1)
+		lo, hi := dt.band.rows(j-1, from.i, to.i)
+		if hi == to.i {
+			insOpen, insExt := dt.gaps.ins(to.i)
+			holdMat = down.mat[to.i]
+			next := dt.mask(to.i, j-1, down.ins[to.i])
+			down.mat[to.i], down.ins[to.i], down.del[to.i] = next+insOpen, next+insExt, next+insOpen
+			hi--
+		} else {
+			holdMat = down.mat[hi+1]
+			down.mat[hi+1], down.ins[hi+1], down.del[hi+1] = inf, inf, inf
+		}
+		for i := hi; i >= lo; i-- {
+			insOpen, insExt := dt.gaps.ins(i)
+			mat := holdMat + dt.cmp(i, dt.a[i], j-1, dt.b[j-1])
+			nextIns, nextDel := down.ins[i], down.del[i+1]
+			holdMat = down.mat[i]
+			down.mat[i] = maxFloat3(mat, nextIns+insOpen, nextDel+delOpen)
+			down.ins[i] = maxFloat3(mat, nextIns+insExt, nextDel+delOpen)
+			down.del[i] = maxFloat3(mat, nextIns+insOpen, nextDel+delExt)
+		}
+	}
+}
+
+func (dt *allgDinTableMem) calcFromUpDownExt(
+	upFrom, upTo, downFrom, downTo cell,
+	start, end allgAction,
+) {
+	if !dt.async {
+		dt.calcFromUpExt(upFrom, upTo, start, dt.upExt)
+		dt.calcFromDownExt(downFrom, downTo, end, dt.downExt)
+		return
+	}
+	dt.wg.Add(2)
+	go func() {
+		dt.calcFromDownExt(downFrom, downTo, end, dt.downExt)
+		dt.wg.Done()
+	}()
+	go func() {
+		dt.calcFromUpExt(upFrom, upTo, start, dt.upExt)
+		dt.wg.Done()
+	}()
+	dt.wg.Wait()
+}
+
+// calcPartExt is calcPart for extended Alligner (Myers-Miller).
+// Path from `from` to `to` continues a move of kind `start` and ends with a move of kind `end`.
+func (dt *allgDinTableMem) calcPartExt(from, to cell, start, end allgAction) []allgAction {
+	res := dt.resBuf[from.i+from.j : to.i+from.j]
+	if from.j == to.j {
+		l := to.i - from.i
+		for i := 0; i < l; i++ {
+			res[i] = actionLeft
+		}
+		return res[:l]
+	}
+
+	size := to.j - from.j
+	sizeFromUp := size / 2
+	sizeFromDown := (size - (size+1)%2) / 2
+
+	dt.calcFromUpDownExt(
+		from,
+		cell{
+			i: to.i,
+			j: from.j + sizeFromUp,
+		},
+		cell{
+			i: from.i,
+			j: to.j - sizeFromDown,
+		},
+		to,
+		start,
+		end,
+	)
+	up, down := dt.upExt, dt.downExt
+	j := from.j + sizeFromUp
+	upI := from.i
+	action := actionUp
+	upEnd := dirMat
+	val := math.Inf(-1)
+	// actionUp check
+	for i := from.i; i <= to.i; i++ {
+		open, ext := dt.gaps.ins(i)
+		curVal, curEnd := maxFloat3DirAlt(
+			up.mat[i]+open, dirMat,
+			up.ins[i]+ext, dirIns,
+			up.del[i]+open, dirDel,
+		)
+		curVal += down.ins[i]
+		if curVal > val {
+			upI = i
+			val = curVal
+			upEnd = curEnd
+		}
+	}
+	// actionUpLeft check
+	for i := from.i; i < to.i; i++ {
+		curVal, curEnd := maxFloat3DirAlt(
+			up.mat[i], dirMat,
+			up.ins[i], dirIns,
+			up.del[i], dirDel,
+		)
+		curVal += down.mat[i+1] + dt.cmp(i, dt.a[i], j, dt.b[j])
+		if curVal > val {
+			upI = i
+			val = curVal
+			upEnd = curEnd
+			action = actionUpLeft
+		}
+	}
+
+	nextTo := cell{
+		i: upI,
+		j: j,
+	}
+	nextFrom := cell{
+		i: upI,
+		j: j + 1,
+	}
+	nextStart := dirIns
+	if action == actionUpLeft {
+		nextFrom.i++
+		nextStart = dirMat
+	}
+
+	res = res[:0:cap(res)]
+	res = append(res, dt.calcPartExt(from, nextTo, start, upEnd)...)
+	res = append(res, action)
+	res = append(res, dt.calcPartExt(nextFrom, to, nextStart, end)...)
+
+	return res
+}
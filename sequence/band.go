@@ -0,0 +1,165 @@
+package sequence
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// band limits table to cells (i, j) with diagonal j-i in [lo, hi]
+type band struct {
+	lo int
+	hi int
+}
+
+// newBand returns band of width k around diagonals from the start to the end of table of a and b,
+// nil if band covers the whole table
+func newBand(lenA, lenB, k int) *band {
+	d := lenB - lenA
+	bd := &band{
+		lo: minInt(0, d) - k,
+		hi: maxInt(0, d) + k,
+	}
+	if bd.lo <= -lenA && bd.hi >= lenB {
+		return nil
+	}
+	return bd
+}
+
+// out reports if cell is outside of band, nil band has all cells
+func (bd *band) out(i, j int) bool {
+	if bd == nil {
+		return false
+	}
+	return j-i < bd.lo || j-i > bd.hi
+}
+
+// cols returns the first and the last column of row i inside of band, column 0 is not included
+func (bd *band) cols(i, lenB int) (int, int) {
+	if bd == nil {
+		return 1, lenB
+	}
+	return maxInt(1, i+bd.lo), minInt(lenB, i+bd.hi)
+}
+
+// rows returns the first and the last row of column j inside of band, rows are limited by [from, to]
+func (bd *band) rows(j, from, to int) (int, int) {
+	if bd == nil {
+		return from, to
+	}
+	return maxInt(from, j-bd.hi), minInt(to, j-bd.lo)
+}
+
+// maxCompare returns the best score of a pair of residues of a and b.
+// Scores of PositionalAlligner are checked for every pair of positions.
+func maxCompare(alg Alligner, a, b string) float64 {
+	m := math.Inf(-1)
+	if p, ok := alg.(PositionalAlligner); ok {
+		for i := 0; i < len(a); i++ {
+			for j := 0; j < len(b); j++ {
+				m = math.Max(m, p.CompareAt(i, a[i], j, b[j]))
+			}
+		}
+		return m
+	}
+	var inA, inB [256]bool
+	for i := 0; i < len(a); i++ {
+		inA[a[i]] = true
+	}
+	for j := 0; j < len(b); j++ {
+		inB[b[j]] = true
+	}
+	for x := range inA {
+		if !inA[x] {
+			continue
+		}
+		for y := range inB {
+			if inB[y] {
+				m = math.Max(m, alg.Compare(byte(x), byte(y)))
+			}
+		}
+	}
+	return m
+}
+
+// maxGap returns the highest penalty of a gap column
+func (g gapModel) maxGap() float64 {
+	m := math.Inf(-1)
+	for i := 0; i <= g.lenA; i++ {
+		open, ext := g.ins(i)
+		m = math.Max(m, open)
+		if g.alg.IsExtended() {
+			m = math.Max(m, ext)
+		}
+	}
+	for j := 0; j <= g.lenB; j++ {
+		open, ext := g.del(j)
+		m = math.Max(m, open)
+		if g.alg.IsExtended() {
+			m = math.Max(m, ext)
+		}
+	}
+	return m
+}
+
+// bandChecker tells if the best allignment inside of band is the best one of the whole table
+type bandChecker struct {
+	lenA   int
+	lenB   int
+	maxCmp float64
+	maxGap float64
+}
+
+func newBandChecker(alg Alligner, a, b string, gaps gapModel) bandChecker {
+	return bandChecker{
+		lenA:   len(a),
+		lenB:   len(b),
+		maxCmp: maxCompare(alg, a, b),
+		maxGap: gaps.maxGap(),
+	}
+}
+
+// bound is the highest score of a path which leaves band of width k.
+// Such path has at least |lenB-lenA|+2(k+1) gap columns, others are pairs.
+func (bc bandChecker) bound(k int) float64 {
+	total := bc.lenA + bc.lenB
+	minGaps := absInt(bc.lenB-bc.lenA) + 2*(k+1)
+	if minGaps > total {
+		return math.Inf(-1)
+	}
+	score := func(gaps int) float64 {
+		return float64((total-gaps)/2)*bc.maxCmp + float64(gaps)*bc.maxGap
+	}
+	return math.Max(score(minGaps), score(total))
+}
+
+// proven reports if score of the best path inside of band of width k is the best score of table
+func (bc bandChecker) proven(score float64, k int) bool {
+	return score >= bc.bound(k)
+}
+
+// bandedScore finds band, starting from width opts.Band and doubling it, which has the best allignment
+// of the whole table. Scores of bands are calculated in linear memory. nil band is the whole table.
+func bandedScore(alg Alligner, a, b string, opts Options) (*band, float64) {
+	gaps := newGapModel(alg, a, b, opts)
+	bc := newBandChecker(alg, a, b, gaps)
+	for k := maxInt(opts.Band, 1); ; k *= 2 {
+		bd := newBand(len(a), len(b), k)
+		st := newScoreTable(alg, a, b, opts, bd)
+		st.calcTable(opts.Threads)
+		score := st.score()
+		if bd == nil || bc.proven(score, k) {
+			return bd, score
+		}
+	}
+}
+
+func checkBand(opts Options) error {
+	if opts.Band < 0 {
+		return errors.Errorf("bad band width %d", opts.Band)
+	}
+	if opts.Band > 0 && opts.Mode == ModeLocal {
+		return errors.New("band is supported only in global and semi-global modes")
+	}
+	return nil
+}
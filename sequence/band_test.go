@@ -0,0 +1,93 @@
+package sequence
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mutate changes, inserts and deletes about rate of residues of s
+func mutate(r *rand.Rand, alphabet, s string, rate float64) string {
+	res := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch x := r.Float64(); {
+		case x < rate/3:
+			res = append(res, alphabet[r.Intn(len(alphabet))])
+		case x < 2*rate/3:
+			res = append(res, s[i], alphabet[r.Intn(len(alphabet))])
+		case x < rate:
+		default:
+			res = append(res, s[i])
+		}
+	}
+	return string(res)
+}
+
+func TestBand(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	tcs := []struct {
+		allg     Alligner
+		alphabet string
+	}{
+		{NewAlligerBLOSUM62(-6, -6), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerBLOSUM62(-11, -1), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerDNA(-10, -1), "ATGC"},
+	}
+	for _, tc := range tcs {
+		for n := 0; n < 30; n++ {
+			a := randomSeq(r, tc.alphabet, 1+r.Intn(60))
+			b := randomSeq(r, tc.alphabet, 1+r.Intn(60))
+			if n%2 == 0 {
+				b = mutate(r, tc.alphabet, a, 0.1)
+			}
+			for _, opts := range []Options{
+				{},
+				{Mode: ModeSemiGlobal, FreeEnds: FreeEnds{StartA: true, EndB: true}},
+				{GapsA: randomGaps(r, len(a))},
+			} {
+				exp, err := AllignWithOptions(tc.allg, a, b, opts)
+				require.NoError(t, err)
+
+				for _, k := range []int{1, 2, 5} {
+					opts.Band = k
+					opts.MemoryOpt = false
+					res, err := AllignWithOptions(tc.allg, a, b, opts)
+					require.NoError(t, err)
+					require.Equal(t, exp.Score, res.Score)
+					require.Equal(t, exp.Score, checkScoreOpts(tc.allg, res.ResA, res.ResB, opts))
+
+					opts.MemoryOpt = true
+					res, err = AllignWithOptions(tc.allg, a, b, opts)
+					require.NoError(t, err)
+					require.Equal(t, exp.Score, res.Score)
+					require.Equal(t, exp.Score, checkScoreOpts(tc.allg, res.ResA, res.ResB, opts))
+
+					score, err := Score(tc.allg, a, b, opts)
+					require.NoError(t, err)
+					require.Equal(t, exp.Score, score)
+				}
+			}
+		}
+	}
+}
+
+func TestBandWidth(t *testing.T) {
+	allg := NewAlligerDNA(-10, -1)
+	a := "ACGTACGTTTGACCAGTACGATCGATCGGGCTAGCTAGCATCGACTAGCTACG"
+	bd, score := bandedScore(allg, a, a, Options{Band: 2, Threads: 1})
+	require.NotNil(t, bd)
+	require.Equal(t, band{lo: -2, hi: 2}, *bd)
+	require.Equal(t, float64(5*len(a)), score)
+
+	bd, _ = bandedScore(allg, a, a[10:], Options{Band: 1, Threads: 1})
+	require.NotNil(t, bd)
+	require.True(t, bd.lo <= -10)
+
+	_, err := AllignWithOptions(allg, a, a, Options{Band: -1})
+	require.Error(t, err)
+	_, err = AllignWithOptions(allg, a, a, Options{Band: 3, Mode: ModeLocal})
+	require.Error(t, err)
+	_, err = Score(allg, a, a, Options{Band: 3, Mode: ModeLocal})
+	require.Error(t, err)
+}
@@ -0,0 +1,413 @@
+package sequence
+
+import (
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TieBreak selects one of co-optimal allignments
+type TieBreak int
+
+const (
+	// TieDefault keeps the fixed preference order of the table
+	TieDefault TieBreak = iota
+	// TieLeftGaps places gaps as close to the start of sequences as possible
+	TieLeftGaps
+	// TieRightGaps places gaps as close to the end of sequences as possible
+	TieRightGaps
+	// TiePreferMatch prefers pairs of identical residues, then gaps, then mismatches
+	TiePreferMatch
+)
+
+// coNode is a state of table: cell (i, j) entered by move s.
+// Linear table has only dirMat nodes, actionStop node is the start of local extended allignment.
+type coNode struct {
+	i int
+	j int
+	s allgAction
+}
+
+// coMove is a move of kind move into node from node `from`
+type coMove struct {
+	move allgAction
+	from coNode
+}
+
+// coTable finds all moves which are on optimal paths of a filled table
+type coTable struct {
+	alg   Alligner
+	dt    allgDinTable
+	a     string
+	b     string
+	local bool
+	ext   bool
+	best  float64
+}
+
+func newCoTable(alg Alligner, a, b string, opts Options) *coTable {
+	ct := &coTable{
+		alg:   alg,
+		dt:    fillTable(alg, a, b, opts, nil),
+		a:     a,
+		b:     b,
+		local: opts.Mode == ModeLocal,
+		ext:   alg.IsExtended(),
+	}
+	ct.best = math.Inf(-1)
+	for _, n := range ct.ends() {
+		ct.best = math.Max(ct.best, ct.value(n))
+	}
+	return ct
+}
+
+// equalScores compares scores which may be summed in different order
+func equalScores(x, y float64) bool {
+	return math.Abs(x-y) <= 1e-9*math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+}
+
+func (ct *coTable) value(n coNode) float64 {
+	switch n.s {
+	case dirIns:
+		return ct.dt.inss[n.i][n.j]
+	case dirDel:
+		return ct.dt.dels[n.i][n.j]
+	case actionStop:
+		return 0
+	}
+	return ct.dt.vals[n.i][n.j]
+}
+
+func (ct *coTable) terminal(n coNode) bool {
+	switch {
+	case ct.local && ct.ext:
+		return n.s == actionStop
+	case ct.local:
+		return ct.dt.vals[n.i][n.j] <= 0
+	}
+	return n.i == 0 && n.j == 0 && n.s == dirMat
+}
+
+// ends returns nodes where optimal allignments may end, local allignment with no positive score is empty
+func (ct *coTable) ends() []coNode {
+	la, lb := len(ct.a), len(ct.b)
+	if !ct.local {
+		if !ct.ext || (la == 0 && lb == 0) {
+			return []coNode{{la, lb, dirMat}}
+		}
+		return []coNode{{la, lb, dirMat}, {la, lb, dirIns}, {la, lb, dirDel}}
+	}
+	best := float64(0)
+	var res []coNode
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			v := ct.dt.vals[i][j]
+			switch {
+			case v <= 0 || (v < best && !equalScores(v, best)):
+			case v > best && !equalScores(v, best):
+				best = v
+				res = append(res[:0], coNode{i, j, dirMat})
+			default:
+				res = append(res, coNode{i, j, dirMat})
+			}
+		}
+	}
+	if res == nil {
+		return []coNode{{0, 0, actionStop}}
+	}
+	return res
+}
+
+func (ct *coTable) isEnd(n coNode) bool {
+	return equalScores(ct.value(n), ct.best)
+}
+
+// moves returns all moves into n which keep the path optimal
+func (ct *coTable) moves(n coNode) []coMove {
+	if ct.terminal(n) {
+		return nil
+	}
+	v := ct.value(n)
+	var res []coMove
+	add := func(move allgAction, from coNode, cost float64) {
+		if equalScores(ct.value(from)+cost, v) {
+			res = append(res, coMove{move: move, from: from})
+		}
+	}
+	i, j := n.i, n.j
+	if !ct.ext {
+		if i > 0 && j > 0 {
+			add(dirMat, coNode{i - 1, j - 1, dirMat}, ct.dt.cmp(i-1, ct.a[i-1], j-1, ct.b[j-1]))
+		}
+		if j > 0 {
+			add(dirIns, coNode{i, j - 1, dirMat}, ct.dt.gaps.insOpen(i))
+		}
+		if i > 0 {
+			add(dirDel, coNode{i - 1, j, dirMat}, ct.dt.gaps.delOpen(j))
+		}
+		return res
+	}
+
+	switch n.s {
+	case dirMat:
+		if i == 0 || j == 0 {
+			return nil
+		}
+		cmp := ct.dt.cmp(i-1, ct.a[i-1], j-1, ct.b[j-1])
+		if ct.local && maxFloat3(ct.dt.vals[i-1][j-1], ct.dt.inss[i-1][j-1], ct.dt.dels[i-1][j-1]) <= 0 {
+			return []coMove{{move: dirMat, from: coNode{i - 1, j - 1, actionStop}}}
+		}
+		for _, s := range []allgAction{dirMat, dirIns, dirDel} {
+			add(dirMat, coNode{i - 1, j - 1, s}, cmp)
+		}
+	case dirIns:
+		if j == 0 {
+			return nil
+		}
+		open, ext := ct.dt.gaps.ins(i)
+		add(dirIns, coNode{i, j - 1, dirMat}, open)
+		add(dirIns, coNode{i, j - 1, dirIns}, ext)
+		add(dirIns, coNode{i, j - 1, dirDel}, open)
+	case dirDel:
+		if i == 0 {
+			return nil
+		}
+		open, ext := ct.dt.gaps.del(j)
+		add(dirDel, coNode{i - 1, j, dirMat}, open)
+		add(dirDel, coNode{i - 1, j, dirIns}, open)
+		add(dirDel, coNode{i - 1, j, dirDel}, ext)
+	}
+	return res
+}
+
+// count returns amount of optimal paths, paths into every node are counted row by row
+func (ct *coTable) count() *big.Int {
+	la, lb := len(ct.a), len(ct.b)
+	states := []allgAction{dirMat}
+	if ct.ext {
+		states = []allgAction{dirMat, dirIns, dirDel}
+	}
+	newRow := func() [][3]*big.Int {
+		row := make([][3]*big.Int, lb+1)
+		for j := range row {
+			for s := range row[j] {
+				row[j][s] = new(big.Int)
+			}
+		}
+		return row
+	}
+	prev, cur := newRow(), newRow()
+	// ends are in row order
+	ends := ct.optimalEnds()
+	one := big.NewInt(1)
+	total := new(big.Int)
+	for i := 0; i <= la; i++ {
+		for j := 0; j <= lb; j++ {
+			for _, s := range states {
+				n := coNode{i, j, s}
+				c := cur[j][s-dirMat].SetInt64(0)
+				if ct.terminal(n) {
+					c.SetInt64(1)
+				}
+				for _, m := range ct.moves(n) {
+					switch {
+					case m.from.s == actionStop:
+						c.Add(c, one)
+					case m.from.i == i:
+						c.Add(c, cur[m.from.j][m.from.s-dirMat])
+					default:
+						c.Add(c, prev[m.from.j][m.from.s-dirMat])
+					}
+				}
+			}
+		}
+		for ; len(ends) > 0 && ends[0].i == i; ends = ends[1:] {
+			if ends[0].s == actionStop {
+				total.Add(total, one)
+				continue
+			}
+			total.Add(total, cur[ends[0].j][ends[0].s-dirMat])
+		}
+		prev, cur = cur, prev
+	}
+	return total
+}
+
+// priority of move into node `to` for tie-break policy, lower is preferred
+func (ct *coTable) priority(tb TieBreak, move allgAction, to coNode) int {
+	if move == actionStop {
+		return 0
+	}
+	switch tb {
+	case TieRightGaps:
+		return [...]int{dirMat: 2, dirIns: 1, dirDel: 0}[move]
+	case TiePreferMatch:
+		if move == dirMat {
+			// start node (0, 0) of extended table is in match state, but has no pair
+			if to.i == 0 || to.j == 0 {
+				return 0
+			}
+			if ct.a[to.i-1] == ct.b[to.j-1] {
+				return 0
+			}
+			return 2
+		}
+		return 1
+	}
+	return [...]int{dirMat: 0, dirIns: 1, dirDel: 2}[move]
+}
+
+// movePriority is priority of the next move of traceback after m,
+// in extended table it is the move into the node m comes from
+func (ct *coTable) movePriority(tb TieBreak, m coMove, to coNode) int {
+	if ct.ext {
+		return ct.priority(tb, m.from.s, m.from)
+	}
+	return ct.priority(tb, m.move, to)
+}
+
+func (ct *coTable) result(n coNode, end coNode, cols []coMove) *Allignment {
+	resA := strings.Builder{}
+	resB := strings.Builder{}
+	i, j := n.i, n.j
+	for k := len(cols) - 1; k >= 0; k-- {
+		switch cols[k].move {
+		case dirMat:
+			resA.WriteByte(ct.a[i])
+			resB.WriteByte(ct.b[j])
+			i++
+			j++
+		case dirIns:
+			resA.WriteByte(ct.alg.Gap())
+			resB.WriteByte(ct.b[j])
+			j++
+		case dirDel:
+			resA.WriteByte(ct.a[i])
+			resB.WriteByte(ct.alg.Gap())
+			i++
+		}
+	}
+	return &Allignment{
+		ResA:  resA.String(),
+		ResB:  resB.String(),
+		Score: ct.best,
+		BegA:  n.i,
+		EndA:  end.i,
+		BegB:  n.j,
+		EndB:  end.j,
+	}
+}
+
+// optimalEnds returns end nodes of optimal allignments
+func (ct *coTable) optimalEnds() []coNode {
+	var res []coNode
+	for _, n := range ct.ends() {
+		if n.s == actionStop || ct.isEnd(n) {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// allign builds one optimal allignment choosing moves by tie-break policy
+func (ct *coTable) allign(tb TieBreak) *Allignment {
+	ends := ct.optimalEnds()
+	end := ends[0]
+	if ct.ext && !ct.local {
+		// end state is chosen as a move into the last cell
+		for _, n := range ends[1:] {
+			if ct.priority(tb, n.s, n) < ct.priority(tb, end.s, end) {
+				end = n
+			}
+		}
+	}
+	var cols []coMove
+	n := end
+	for !ct.terminal(n) {
+		moves := ct.moves(n)
+		if len(moves) == 0 {
+			break
+		}
+		m := moves[0]
+		for _, o := range moves[1:] {
+			if ct.movePriority(tb, o, n) < ct.movePriority(tb, m, n) {
+				m = o
+			}
+		}
+		cols = append(cols, m)
+		n = m.from
+	}
+	return ct.result(n, end, cols)
+}
+
+// enumerate returns up to limit optimal allignments
+func (ct *coTable) enumerate(tb TieBreak, limit int) []*Allignment {
+	var res []*Allignment
+	var cols []coMove
+	var walk func(n, end coNode)
+	walk = func(n, end coNode) {
+		if len(res) >= limit {
+			return
+		}
+		if ct.terminal(n) {
+			res = append(res, ct.result(n, end, cols))
+			return
+		}
+		moves := ct.moves(n)
+		for p := 0; p < 3; p++ {
+			for _, m := range moves {
+				if ct.movePriority(tb, m, n) != p {
+					continue
+				}
+				cols = append(cols, m)
+				walk(m.from, end)
+				cols = cols[:len(cols)-1]
+			}
+		}
+	}
+	for _, end := range ct.optimalEnds() {
+		walk(end, end)
+	}
+	return res
+}
+
+func checkCoOptimal(alg Alligner, a, b string, opts Options) (Options, error) {
+	if err := checkSeqs(alg, a, b); err != nil {
+		return opts, err
+	}
+	if err := checkGaps(opts, a, b); err != nil {
+		return opts, err
+	}
+	switch opts.Mode {
+	case ModeGlobal, ModeSemiGlobal, ModeLocal:
+	default:
+		return opts, errors.Errorf("unknown mode %d", opts.Mode)
+	}
+	if opts.Threads <= 0 {
+		opts.Threads = 1
+	}
+	return opts, nil
+}
+
+// CountCoOptimal returns amount of different allignments of a and b with the best score in mode of opts.
+// opts.MemoryOpt is ignored, full table is used.
+func CountCoOptimal(alg Alligner, a, b string, opts Options) (*big.Int, error) {
+	opts, err := checkCoOptimal(alg, a, b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newCoTable(alg, a, b, opts).count(), nil
+}
+
+// EnumerateCoOptimal returns up to limit different allignments of a and b with the best score in mode of opts,
+// they are ordered by opts.TieBreak preference (TieDefault is treated as TieLeftGaps).
+// opts.MemoryOpt is ignored, full table is used.
+func EnumerateCoOptimal(alg Alligner, a, b string, limit int, opts Options) ([]*Allignment, error) {
+	opts, err := checkCoOptimal(alg, a, b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newCoTable(alg, a, b, opts).enumerate(opts.TieBreak, limit), nil
+}
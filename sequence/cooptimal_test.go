@@ -0,0 +1,185 @@
+package sequence
+
+import (
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// allAllignments returns every global allignment of a and b
+func allAllignments(allg Alligner, a, b string) [][2]string {
+	if a == "" && b == "" {
+		return [][2]string{{"", ""}}
+	}
+	var res [][2]string
+	gap := string(allg.Gap())
+	if a != "" && b != "" {
+		for _, r := range allAllignments(allg, a[1:], b[1:]) {
+			res = append(res, [2]string{a[:1] + r[0], b[:1] + r[1]})
+		}
+	}
+	if b != "" {
+		for _, r := range allAllignments(allg, a, b[1:]) {
+			res = append(res, [2]string{gap + r[0], b[:1] + r[1]})
+		}
+	}
+	if a != "" {
+		for _, r := range allAllignments(allg, a[1:], b) {
+			res = append(res, [2]string{a[:1] + r[0], gap + r[1]})
+		}
+	}
+	return res
+}
+
+// countLocal counts local allignments with score, every prefix of local allignment has positive score
+func countLocal(allg Alligner, a, b string, score float64) int64 {
+	res := int64(0)
+	for i1 := 0; i1 < len(a); i1++ {
+		for i2 := i1 + 1; i2 <= len(a); i2++ {
+			for j1 := 0; j1 < len(b); j1++ {
+				for j2 := j1 + 1; j2 <= len(b); j2++ {
+					for _, al := range allAllignments(allg, a[i1:i2], b[j1:j2]) {
+						positive := true
+						for c := 1; c < len(al[0]); c++ {
+							positive = positive && checkScore(allg, al[0][:c], al[1][:c]) > 0
+						}
+						if positive && equalScores(checkScore(allg, al[0], al[1]), score) {
+							res++
+						}
+					}
+				}
+			}
+		}
+	}
+	return res
+}
+
+func TestCountCoOptimal(t *testing.T) {
+	allg := NewAlligerDNA(-10, -10)
+	count, err := CountCoOptimal(allg, "AA", "A", Options{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(2), count)
+	count, err = CountCoOptimal(allg, "ACGT", "ACGT", Options{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1), count)
+	count, err = CountCoOptimal(allg, "ACGT", "TTTT", Options{Mode: ModeLocal})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(4), count)
+	count, err = CountCoOptimal(allg, "AAAA", "CCCC", Options{Mode: ModeLocal})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1), count)
+
+	// with linear gaps every allignment of polyA with polyA of half length without insertions is optimal,
+	// with affine ones deletions are a single block
+	a := strings.Repeat("A", 200)
+	count, err = CountCoOptimal(allg, a, a[:100], Options{})
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Binomial(200, 100), count)
+	count, err = CountCoOptimal(NewAlligerDNA(-10, -1), a, a[:100], Options{})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(101), count)
+
+	r := rand.New(rand.NewSource(11))
+	for _, allg := range []Alligner{NewAlligerDNA(-4, -4), NewAlligerDNA(-8, -2), NewAlligerDNA(-5, 0)} {
+		for n := 0; n < 40; n++ {
+			a, b := randomSeq(r, "AC", r.Intn(6)), randomSeq(r, "AC", r.Intn(6))
+			for _, opts := range []Options{{}, {Mode: ModeSemiGlobal, FreeEnds: FreeEnds{StartA: true, EndB: true}}} {
+				best, err := AllignWithOptions(allg, a, b, opts)
+				require.NoError(t, err)
+				exp := int64(0)
+				for _, al := range allAllignments(allg, a, b) {
+					if equalScores(checkScoreOpts(allg, al[0], al[1], opts), best.Score) {
+						exp++
+					}
+				}
+				count, err := CountCoOptimal(allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, big.NewInt(exp), count, "%s %s", a, b)
+
+				res, err := EnumerateCoOptimal(allg, a, b, 1000, opts)
+				require.NoError(t, err)
+				require.Len(t, res, int(exp))
+				seen := make(map[[2]string]bool)
+				for _, al := range res {
+					require.Equal(t, best.Score, checkScoreOpts(allg, al.ResA, al.ResB, opts))
+					require.False(t, seen[[2]string{al.ResA, al.ResB}])
+					seen[[2]string{al.ResA, al.ResB}] = true
+				}
+			}
+
+			opts := Options{Mode: ModeLocal}
+			best, err := AllignWithOptions(allg, a, b, opts)
+			require.NoError(t, err)
+			count, err := CountCoOptimal(allg, a, b, opts)
+			require.NoError(t, err)
+			if best.Score > 0 {
+				require.Equal(t, big.NewInt(countLocal(allg, a, b, best.Score)), count, "%s %s", a, b)
+			}
+			res, err := EnumerateCoOptimal(allg, a, b, 1000, opts)
+			require.NoError(t, err)
+			require.Len(t, res, int(count.Int64()))
+			for _, al := range res {
+				require.Equal(t, best.Score, al.Score)
+				require.Equal(t, best.Score, checkScore(allg, al.ResA, al.ResB))
+				require.Equal(t, removeGaps(allg, al.ResA), a[al.BegA:al.EndA])
+				require.Equal(t, removeGaps(allg, al.ResB), b[al.BegB:al.EndB])
+			}
+		}
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	for _, allg := range []Alligner{NewAlligerDNA(-10, -10), NewAlligerDNA(-10, -1)} {
+		tcs := []struct {
+			tb         TieBreak
+			resA, resB string
+		}{
+			{TieLeftGaps, "AAAT", "-AAT"},
+			{TieRightGaps, "AAAT", "AA-T"},
+			{TiePreferMatch, "AAAT", "-AAT"},
+		}
+		for _, tc := range tcs {
+			res, err := AllignWithOptions(allg, "AAAT", "AAT", Options{TieBreak: tc.tb})
+			require.NoError(t, err)
+			require.Equal(t, []string{tc.resA, tc.resB}, []string{res.ResA, res.ResB}, "tie-break %d", tc.tb)
+			require.Equal(t, float64(5), res.Score)
+		}
+	}
+
+	allg := NewAlligerDNA(-2, -2)
+	res, err := AllignWithOptions(allg, "A", "C", Options{TieBreak: TieLeftGaps})
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "C"}, []string{res.ResA, res.ResB})
+	res, err = AllignWithOptions(allg, "A", "C", Options{TieBreak: TiePreferMatch})
+	require.NoError(t, err)
+	require.Equal(t, float64(-4), res.Score)
+	require.Equal(t, 2, len(res.ResA))
+
+	res, err = AllignWithOptions(allg, "GGACGTGG", "ACGT", Options{Mode: ModeLocal, TieBreak: TieRightGaps})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 6, 0, 4}, []int{res.BegA, res.EndA, res.BegB, res.EndB})
+
+	_, err = AllignWithOptions(allg, "A", "C", Options{TieBreak: TieLeftGaps, MemoryOpt: true})
+	require.Error(t, err)
+}
+
+func TestEnumeratePreferMatch(t *testing.T) {
+	allg := NewAlligerBLOSUM62(-10, -1)
+	res, err := EnumerateCoOptimal(allg, "AWK", "AWK", 3, Options{TieBreak: TiePreferMatch})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, []string{"AWK", "AWK"}, []string{res[0].ResA, res[0].ResB})
+
+	dna := NewAlligerDNA(-10, -1)
+	for _, mode := range []Mode{ModeGlobal, ModeLocal} {
+		res, err = EnumerateCoOptimal(dna, "AAAT", "AAT", 5, Options{Mode: mode, TieBreak: TiePreferMatch})
+		require.NoError(t, err)
+		require.NotEmpty(t, res)
+		best, err := AllignWithOptions(dna, "AAAT", "AAT", Options{Mode: mode, TieBreak: TiePreferMatch})
+		require.NoError(t, err)
+		require.Equal(t, best, res[0])
+	}
+}
@@ -0,0 +1,109 @@
+package sequence
+
+import "math"
+
+// PairStats are counts of columns of pairwise allignment
+type PairStats struct {
+	// Columns is amount of columns without gaps
+	Columns   int
+	Identical int
+	// Transitions and Transversions count mismatches of nucleotides A, C, G, T, U
+	Transitions   int
+	Transversions int
+	// Gaps is amount of columns with a gap
+	Gaps int
+}
+
+func nucleotideBase(b byte) (byte, bool) {
+	switch b {
+	case 'A', 'C', 'G', 'T':
+		return b, true
+	case 'U':
+		return 'T', true
+	case 'a', 'c', 'g', 't':
+		return b - 'a' + 'A', true
+	case 'u':
+		return 'T', true
+	}
+	return 0, false
+}
+
+// AllignmentStats counts columns of alligned resA and resB, case is ignored
+func AllignmentStats(alg Alligner, resA, resB string) PairStats {
+	s := PairStats{}
+	for i := 0; i < len(resA) && i < len(resB); i++ {
+		a, b := resA[i], resB[i]
+		if a == alg.Gap() || b == alg.Gap() {
+			s.Gaps++
+			continue
+		}
+		s.Columns++
+		na, okA := nucleotideBase(a)
+		nb, okB := nucleotideBase(b)
+		switch {
+		case a == b, okA && okB && na == nb, isLetter(a) && a^0x20 == b:
+			s.Identical++
+		case okA && okB && isPurine(na) == isPurine(nb):
+			s.Transitions++
+		case okA && okB:
+			s.Transversions++
+		}
+	}
+	return s
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// Identity is percent of identical residues among columns without gaps
+func (s PairStats) Identity() float64 {
+	if s.Columns == 0 {
+		return 0
+	}
+	return 100 * float64(s.Identical) / float64(s.Columns)
+}
+
+// PDistance is proportion of differing residues among columns without gaps
+func (s PairStats) PDistance() float64 {
+	if s.Columns == 0 {
+		return 1
+	}
+	return 1 - float64(s.Identical)/float64(s.Columns)
+}
+
+// PoissonDistance is p-distance corrected for multiple substitutions: -ln(1 - p).
+// It is +Inf for saturated sequences.
+func (s PairStats) PoissonDistance() float64 {
+	return negLog(1 - s.PDistance())
+}
+
+// KimuraProteinDistance is Kimura (1983) approximation for proteins: -ln(1 - p - 0.2p^2).
+// It is +Inf for saturated sequences.
+func (s PairStats) KimuraProteinDistance() float64 {
+	p := s.PDistance()
+	return negLog(1 - p - 0.2*p*p)
+}
+
+// Kimura2PDistance is Kimura two-parameter distance for nucleotides:
+// -ln(1 - 2P - Q)/2 - ln(1 - 2Q)/4, P and Q are proportions of transitions and transversions.
+// It is +Inf for saturated sequences.
+func (s PairStats) Kimura2PDistance() float64 {
+	if s.Columns == 0 {
+		return math.Inf(1)
+	}
+	p := float64(s.Transitions) / float64(s.Columns)
+	q := float64(s.Transversions) / float64(s.Columns)
+	return negLog(1-2*p-q)/2 + negLog(1-2*q)/4
+}
+
+func negLog(x float64) float64 {
+	switch {
+	case x <= 0:
+		return math.Inf(1)
+	case x == 1:
+		// avoid -0 for identical sequences
+		return 0
+	}
+	return -math.Log(x)
+}
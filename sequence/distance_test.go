@@ -0,0 +1,59 @@
+package sequence
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllignmentStats(t *testing.T) {
+	allg := NewAlligerNucleotide(-5, -5, DefaultNucleotideScores)
+	s := AllignmentStats(allg, "ACGT-AcGUA", "AGAT-TCGTA")
+	require.Equal(t, PairStats{Columns: 9, Identical: 6, Transitions: 1, Transversions: 2, Gaps: 1}, s)
+	require.InDelta(t, 100*6/9.0, s.Identity(), 1e-9)
+	require.InDelta(t, 3/9.0, s.PDistance(), 1e-9)
+	require.InDelta(t, -math.Log(6/9.0), s.PoissonDistance(), 1e-9)
+	p := 3 / 9.0
+	require.InDelta(t, -math.Log(1-p-0.2*p*p), s.KimuraProteinDistance(), 1e-9)
+	P, Q := 1/9.0, 2/9.0
+	require.InDelta(t, -math.Log(1-2*P-Q)/2-math.Log(1-2*Q)/4, s.Kimura2PDistance(), 1e-9)
+
+	s = AllignmentStats(allg, "AC", "GT")
+	require.Equal(t, 0.0, s.Identity())
+	require.True(t, math.IsInf(s.PoissonDistance(), 1))
+	require.True(t, math.IsInf(s.Kimura2PDistance(), 1))
+}
+
+func TestAllVsAll(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	allg := NewAlligerBLOSUM62(-11, -1)
+	seqs := make([]string, 7)
+	for i := range seqs {
+		seqs[i] = randomSeq(r, "ARNDCQEGHILKMFPSTWYV", 20+r.Intn(30))
+	}
+	pairAlg := func(i, j int) Alligner { return allg }
+	for _, opts := range []Options{{Threads: 1}, {Threads: 4, Mode: ModeLocal}} {
+		scores, err := AllVsAll(pairAlg, seqs, opts, false)
+		require.NoError(t, err)
+		require.Nil(t, scores.Stats)
+		full, err := AllVsAll(pairAlg, seqs, opts, true)
+		require.NoError(t, err)
+		for i := range seqs {
+			for j := range seqs {
+				opts1 := opts
+				opts1.Threads = 1
+				res, err := AllignWithOptions(allg, seqs[i], seqs[j], opts1)
+				require.NoError(t, err)
+				require.Equal(t, res.Score, scores.Scores[i][j], "%d %d", i, j)
+				require.Equal(t, res.Score, full.Scores[i][j], "%d %d", i, j)
+				require.Equal(t, full.Stats[j][i], full.Stats[i][j])
+			}
+			require.Equal(t, 100.0, full.Stats[i][i].Identity())
+		}
+	}
+
+	_, err := AllVsAll(pairAlg, []string{"AAA", "AJA"}, Options{Threads: 2}, false)
+	require.Error(t, err)
+}
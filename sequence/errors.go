@@ -1,3 +1,8 @@
 package sequence
 
+import "github.com/pkg/errors"
+
 const SwitchErr = 0
+
+// ErrBadSeq is returned when sequence has symbols which are not in Alligner alphabet
+var ErrBadSeq = errors.New("bad seq")
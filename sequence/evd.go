@@ -0,0 +1,169 @@
+package sequence
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// Gumbel is extreme value distribution of the best local allignment scores:
+// P(S >= x) = 1 - exp(-exp(-Lambda*(x-Mu)))
+type Gumbel struct {
+	Mu     float64
+	Lambda float64
+}
+
+// PValue returns probability of score at least x
+func (g Gumbel) PValue(x float64) float64 {
+	return -math.Expm1(-math.Exp(-g.Lambda * (x - g.Mu)))
+}
+
+// FitGumbel fits Gumbel distribution to scores by maximum likelihood
+func FitGumbel(scores []float64) (Gumbel, error) {
+	if len(scores) < 2 {
+		return Gumbel{}, errors.Errorf("%d scores are not enough to fit distribution", len(scores))
+	}
+	if allEqual(scores) {
+		return Gumbel{}, errors.New("all scores are equal")
+	}
+	mean, low := float64(0), math.Inf(1)
+	for _, s := range scores {
+		mean += s
+		low = math.Min(low, s)
+	}
+	mean /= float64(len(scores))
+	variance := float64(0)
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+
+	// sums of exp(-lambda*x) are shifted by the lowest score, so they do not overflow
+	sums := func(lambda float64) (float64, float64) {
+		sum, weighted := float64(0), float64(0)
+		for _, s := range scores {
+			w := math.Exp(-lambda * (s - low))
+			sum += w
+			weighted += w * s
+		}
+		return sum, weighted
+	}
+	// likelihood equation 1/lambda - mean + sum(x*exp(-lambda*x))/sum(exp(-lambda*x)) = 0
+	// has decreasing left part, so root is found by bisection
+	eq := func(lambda float64) float64 {
+		sum, weighted := sums(lambda)
+		return 1/lambda - mean + weighted/sum
+	}
+	// moments estimate is the start of bracket
+	lo := math.Pi / math.Sqrt(6*variance/float64(len(scores)-1))
+	hi := lo
+	for eq(lo) < 0 {
+		lo /= 2
+	}
+	for eq(hi) > 0 {
+		hi *= 2
+	}
+	for n := 0; n < 200 && hi-lo > 1e-12*hi; n++ {
+		mid := (lo + hi) / 2
+		if eq(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	lambda := (lo + hi) / 2
+	sum, _ := sums(lambda)
+	mu := low - math.Log(sum/float64(len(scores)))/lambda
+	return Gumbel{Mu: mu, Lambda: lambda}, nil
+}
+
+// allEqual reports if all scores are the same, their variance may be not exactly 0 because of rounding
+func allEqual(scores []float64) bool {
+	for _, s := range scores {
+		if s != scores[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// residueSampler makes random sequences with residue frequencies of background
+type residueSampler struct {
+	residues []byte
+	cumul    []float64
+}
+
+func newResidueSampler(bg Background) (*residueSampler, error) {
+	res, freqs, err := bg.residues()
+	if err != nil {
+		return nil, err
+	}
+	cumul := make([]float64, len(freqs))
+	total := float64(0)
+	for i, f := range freqs {
+		total += f
+		cumul[i] = total
+	}
+	return &residueSampler{residues: res, cumul: cumul}, nil
+}
+
+func (rs *residueSampler) seq(r *rand.Rand, l int) string {
+	res := make([]byte, l)
+	for i := range res {
+		x := r.Float64() * rs.cumul[len(rs.cumul)-1]
+		k := 0
+		for k < len(rs.cumul)-1 && rs.cumul[k] <= x {
+			k++
+		}
+		res[i] = rs.residues[k]
+	}
+	return string(res)
+}
+
+// EstimateOptions configures EstimateParams
+type EstimateOptions struct {
+	// Pairs is amount of pairs of random sequences
+	Pairs int
+	// Length is length of random sequences
+	Length int
+	// Seed of random generator, the same seed gives the same estimate
+	Seed int64
+}
+
+// DefaultEstimateOptions are used by EstimateParams for zero options
+var DefaultEstimateOptions = EstimateOptions{Pairs: 500, Length: 200, Seed: 1}
+
+// EstimateParams estimates Karlin-Altschul parameters of local allignment of alg (with gaps),
+// Gumbel distribution is fitted to the best scores of pairs of random sequences with residue frequencies bg,
+// K = exp(Lambda*Mu) / Length^2. H is not estimated. Estimate has no edge correction,
+// so it is biased for sequences much longer than Length.
+func EstimateParams(alg Alligner, bg Background, opts EstimateOptions) (KarlinParams, error) {
+	if opts.Pairs == 0 && opts.Length == 0 {
+		opts = DefaultEstimateOptions
+	}
+	if opts.Pairs < 2 || opts.Length < 1 {
+		return KarlinParams{}, errors.Errorf("bad estimate of %d pairs of length %d", opts.Pairs, opts.Length)
+	}
+	if _, err := newScoreLattice(alg, bg); err != nil {
+		return KarlinParams{}, err
+	}
+	rs, err := newResidueSampler(bg)
+	if err != nil {
+		return KarlinParams{}, err
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+	scores := make([]float64, opts.Pairs)
+	for i := range scores {
+		a, b := rs.seq(r, opts.Length), rs.seq(r, opts.Length)
+		scores[i], err = Score(alg, a, b, Options{Mode: ModeLocal, Threads: 1})
+		if err != nil {
+			return KarlinParams{}, err
+		}
+	}
+	g, err := FitGumbel(scores)
+	if err != nil {
+		return KarlinParams{}, errors.Wrap(ErrNoStatistics, err.Error())
+	}
+	l := float64(opts.Length)
+	return KarlinParams{Lambda: g.Lambda, K: math.Exp(g.Lambda*g.Mu) / (l * l)}, nil
+}
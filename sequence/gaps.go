@@ -0,0 +1,199 @@
+package sequence
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadGaps is returned when position-specific gap penalties do not fit the sequence
+var ErrBadGaps = errors.New("bad gap penalties")
+
+// PositionGaps are gap penalties of a sequence by position of gap: Open[k] and Extend[k] are used
+// for gap after the first k residues, so they have len(seq)+1 values. nil slice means penalty of Alligner.
+// Extend is used only by extended Alligner.
+type PositionGaps struct {
+	Open   []float64
+	Extend []float64
+}
+
+func (p *PositionGaps) check(l int) error {
+	if p == nil {
+		return nil
+	}
+	if p.Open != nil && len(p.Open) != l+1 {
+		return errors.Wrapf(ErrBadGaps, "%d gap open penalties for sequence of length %d, expected %d", len(p.Open), l, l+1)
+	}
+	if p.Extend != nil && len(p.Extend) != l+1 {
+		return errors.Wrapf(ErrBadGaps, "%d gap extend penalties for sequence of length %d, expected %d", len(p.Extend), l, l+1)
+	}
+	return nil
+}
+
+func (p *PositionGaps) open(alg Alligner, k int) float64 {
+	if p == nil || p.Open == nil {
+		return alg.GapOpen()
+	}
+	return p.Open[k]
+}
+
+func (p *PositionGaps) extend(alg Alligner, k int) float64 {
+	if p == nil || p.Extend == nil {
+		return alg.GapExtend()
+	}
+	return p.Extend[k]
+}
+
+// ParsePositionGaps reads gap penalties of sequence of length l. Lines starting with '#' are comments,
+// other lines are "position open [extend]", position is k or range from-to (inclusive) of gap positions
+// 0..l, where gap at position k is after the first k residues. Not listed positions get gapOpen and gapExtend.
+func ParsePositionGaps(r io.Reader, l int, gapOpen, gapExtend float64) (*PositionGaps, error) {
+	g := &PositionGaps{Open: make([]float64, l+1), Extend: make([]float64, l+1)}
+	for k := range g.Open {
+		g.Open[k], g.Extend[k] = gapOpen, gapExtend
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, errors.Wrapf(ErrBadGaps, "line %d: expected position, open and optional extend", line)
+		}
+		from, to, err := parseGapRange(fields[0], l)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "line %d", line)
+		}
+		open, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrBadGaps, "line %d: bad gap open %q", line, fields[1])
+		}
+		ext := open
+		if len(fields) == 3 {
+			ext, err = strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return nil, errors.Wrapf(ErrBadGaps, "line %d: bad gap extend %q", line, fields[2])
+			}
+		}
+		for k := from; k <= to; k++ {
+			g.Open[k], g.Extend[k] = open, ext
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func parseGapRange(s string, l int) (int, int, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	from, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, errors.Wrapf(ErrBadGaps, "bad position %q", s)
+	}
+	to := from
+	if len(bounds) == 2 {
+		to, err = strconv.Atoi(bounds[1])
+		if err != nil {
+			return 0, 0, errors.Wrapf(ErrBadGaps, "bad position %q", s)
+		}
+	}
+	if from < 0 || to < from || to > l {
+		return 0, 0, errors.Wrapf(ErrBadGaps, "position %q is out of 0-%d", s, l)
+	}
+	return from, to, nil
+}
+
+func checkGaps(opts Options, a, b string) error {
+	if err := opts.GapsA.check(len(a)); err != nil {
+		return errors.WithMessage(err, "seq a")
+	}
+	if err := opts.GapsB.check(len(b)); err != nil {
+		return errors.WithMessage(err, "seq b")
+	}
+	return nil
+}
+
+// gapModel gives gap penalties for moves in the table.
+// Insertion (gap in a) moves along row i, deletion (gap in b) moves along column j.
+type gapModel struct {
+	alg   Alligner
+	ends  FreeEnds
+	gapsA *PositionGaps
+	gapsB *PositionGaps
+	lenA  int
+	lenB  int
+}
+
+func newGapModel(alg Alligner, a, b string, opts Options) gapModel {
+	g := gapModel{
+		alg:   alg,
+		gapsA: opts.GapsA,
+		gapsB: opts.GapsB,
+		lenA:  len(a),
+		lenB:  len(b),
+	}
+	if opts.Mode == ModeSemiGlobal {
+		g.ends = opts.FreeEnds
+	}
+	return g
+}
+
+func (g gapModel) insFree(i int) bool {
+	return (i == 0 && g.ends.StartB) || (i == g.lenA && g.ends.EndB)
+}
+
+func (g gapModel) delFree(j int) bool {
+	return (j == 0 && g.ends.StartA) || (j == g.lenB && g.ends.EndA)
+}
+
+func (g gapModel) insOpen(i int) float64 {
+	if g.insFree(i) {
+		return 0
+	}
+	return g.gapsA.open(g.alg, i)
+}
+
+func (g gapModel) delOpen(j int) float64 {
+	if g.delFree(j) {
+		return 0
+	}
+	return g.gapsB.open(g.alg, j)
+}
+
+func (g gapModel) ins(i int) (float64, float64) {
+	if g.insFree(i) {
+		return 0, 0
+	}
+	return g.gapsA.open(g.alg, i), g.gapsA.extend(g.alg, i)
+}
+
+func (g gapModel) del(j int) (float64, float64) {
+	if g.delFree(j) {
+		return 0, 0
+	}
+	return g.gapsB.open(g.alg, j), g.gapsB.extend(g.alg, j)
+}
+
+// inf is a score lower than any path through the table can get with gaps only
+func (g gapModel) inf() float64 {
+	open, ext := g.alg.GapOpen(), g.alg.GapExtend()
+	for i := 0; i <= g.lenA; i++ {
+		o, e := g.ins(i)
+		open, ext = math.Min(open, o), math.Min(ext, e)
+	}
+	for j := 0; j <= g.lenB; j++ {
+		o, e := g.del(j)
+		open, ext = math.Min(open, o), math.Min(ext, e)
+	}
+	return 2*open + float64(g.lenA+g.lenB)*ext - 10000
+}
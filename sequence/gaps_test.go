@@ -0,0 +1,90 @@
+package sequence
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func randomGaps(r *rand.Rand, l int) *PositionGaps {
+	g := &PositionGaps{Open: make([]float64, l+1), Extend: make([]float64, l+1)}
+	for k := range g.Open {
+		g.Open[k] = -float64(2 + r.Intn(12))
+		g.Extend[k] = -float64(1 + r.Intn(3))
+	}
+	return g
+}
+
+func TestPositionGaps(t *testing.T) {
+	for _, allg := range []Alligner{NewAlligerDNA(-10, -10), NewAlligerDNA(-10, -1)} {
+		cheap := &PositionGaps{Open: []float64{-10, -10, -10, -1, -10, -10, -10}}
+		for _, opts := range []Options{{}, {MemoryOpt: true}, {Threads: 4}} {
+			opts.GapsB = cheap
+			res, err := AllignWithOptions(allg, "AAAAAAA", "AAAAAA", opts)
+			require.NoError(t, err)
+			require.Equal(t, "AAAAAAA", res.ResA)
+			require.Equal(t, "AAA-AAA", res.ResB)
+			require.Equal(t, float64(29), res.Score)
+		}
+	}
+
+	r := rand.New(rand.NewSource(3))
+	for _, allg := range []Alligner{NewAlligerBLOSUM62(-6, -6), NewAlligerBLOSUM62(-11, -1)} {
+		for n := 0; n < 30; n++ {
+			a, b := randomSeq(r, "ARNDCQEGHILKMFPSTWYV", 10+r.Intn(40)), randomSeq(r, "ARNDCQEGHILKMFPSTWYV", 10+r.Intn(40))
+			gapsA, gapsB := randomGaps(r, len(a)), randomGaps(r, len(b))
+			for _, opts := range []Options{
+				{GapsA: gapsA, GapsB: gapsB},
+				{GapsA: gapsA, GapsB: gapsB, Threads: 3},
+				{GapsA: gapsA},
+				{GapsB: gapsB, Mode: ModeSemiGlobal, FreeEnds: FreeEnds{StartA: true, EndB: true}},
+			} {
+				exp, err := AllignWithOptions(allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, exp.Score, checkScoreOpts(allg, exp.ResA, exp.ResB, opts))
+
+				opts.MemoryOpt = true
+				res, err := AllignWithOptions(allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, exp.Score, res.Score)
+				require.Equal(t, exp.Score, checkScoreOpts(allg, res.ResA, res.ResB, opts))
+
+				score, err := Score(allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, exp.Score, score)
+			}
+
+			opts := Options{Mode: ModeLocal, GapsA: gapsA, GapsB: gapsB}
+			res, err := AllignWithOptions(allg, a, b, opts)
+			require.NoError(t, err)
+			score, err := Score(allg, a, b, opts)
+			require.NoError(t, err)
+			require.Equal(t, res.Score, score)
+		}
+	}
+
+	allg := NewAlligerDNA(-10, -1)
+	_, err := AllignWithOptions(allg, "ACGT", "ACG", Options{GapsA: &PositionGaps{Open: make([]float64, 4)}})
+	require.Equal(t, ErrBadGaps, errors.Cause(err))
+	_, err = Score(allg, "ACGT", "ACG", Options{GapsB: &PositionGaps{Extend: make([]float64, 3)}})
+	require.Equal(t, ErrBadGaps, errors.Cause(err))
+}
+
+func TestParsePositionGaps(t *testing.T) {
+	g, err := ParsePositionGaps(strings.NewReader(`
+# helix 2-4
+2-4 -20 -5
+0 -1
+`), 5, -10, -1)
+	require.NoError(t, err)
+	require.Equal(t, []float64{-1, -10, -20, -20, -20, -10}, g.Open)
+	require.Equal(t, []float64{-1, -1, -5, -5, -5, -1}, g.Extend)
+
+	for i, tc := range []string{"1\n", "x -1\n", "1 x\n", "1 -1 x\n", "6 -1\n", "3-2 -1\n", "-1 -1\n", "1 -1 -1 -1\n"} {
+		_, err := ParsePositionGaps(strings.NewReader(tc), 5, -10, -1)
+		require.Equal(t, ErrBadGaps, errors.Cause(err), "test %d", i)
+	}
+}
@@ -0,0 +1,335 @@
+package sequence
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoStatistics is returned when scores have no Karlin-Altschul statistics:
+// expected score of a pair of random residues is not negative or no pair has positive score
+var ErrNoStatistics = errors.New("no Karlin-Altschul statistics")
+
+// Background is frequency of residues in random sequences, frequencies are normalized by the sum of them
+type Background map[byte]float64
+
+// RobinsonFrequencies are amino acid frequencies of Robinson & Robinson (1991), used by BLAST
+var RobinsonFrequencies = Background{
+	'A': 78.05, 'R': 51.29, 'N': 44.87, 'D': 53.64, 'C': 19.25,
+	'Q': 42.64, 'E': 62.95, 'G': 73.77, 'H': 21.99, 'I': 51.42,
+	'L': 90.19, 'K': 57.44, 'M': 22.43, 'F': 38.56, 'P': 52.03,
+	'S': 71.20, 'T': 58.41, 'W': 13.30, 'Y': 32.16, 'V': 64.41,
+}
+
+// UniformBackground returns equal frequencies of residues of alphabet, e.g. "ACGT" for DNA
+func UniformBackground(alphabet string) Background {
+	bg := make(Background, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		bg[alphabet[i]] = 1
+	}
+	return bg
+}
+
+// residues returns residues of bg in fixed order with normalized frequencies
+func (bg Background) residues() ([]byte, []float64, error) {
+	res := make([]byte, 0, len(bg))
+	total := float64(0)
+	for r, f := range bg {
+		if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, nil, errors.Errorf("bad frequency %v of %q", f, r)
+		}
+		if f > 0 {
+			res = append(res, r)
+			total += f
+		}
+	}
+	if total == 0 {
+		return nil, nil, errors.New("empty background")
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	freqs := make([]float64, len(res))
+	for i, r := range res {
+		freqs[i] = bg[r] / total
+	}
+	return res, freqs, nil
+}
+
+// KarlinParams are Karlin-Altschul parameters of local allignment scores: amount of chance allignments
+// with score at least S of sequences of length m and n is K*m*n*exp(-Lambda*S).
+// H is relative entropy of target and background frequencies in nats, 0 if unknown.
+type KarlinParams struct {
+	Lambda float64
+	K      float64
+	H      float64
+}
+
+// BitScore returns score normalized to bits
+func (p KarlinParams) BitScore(score float64) float64 {
+	return (p.Lambda*score - math.Log(p.K)) / math.Ln2
+}
+
+// EValue returns expected amount of chance allignments with score at least score
+// of query of length m against database of n residues
+func (p KarlinParams) EValue(score float64, m, n int) float64 {
+	return p.K * float64(m) * float64(n) * math.Exp(-p.Lambda*score)
+}
+
+// scoreLattice is distribution of score of a pair of random residues, scores are integers
+// probs[s-low] and original score is integer score multiplied by unit
+type scoreLattice struct {
+	low   int
+	probs []float64
+	unit  float64
+}
+
+// latticeScales are tried to make scores integer, scores are rounded with the last one
+var latticeScales = []float64{1, 2, 3, 4, 5, 6, 8, 10, 100, 1000}
+
+func newScoreLattice(alg Alligner, bg Background) (*scoreLattice, error) {
+	res, freqs, err := bg.residues()
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]float64, 0, len(res)*len(res))
+	for _, a := range res {
+		for _, b := range res {
+			scores = append(scores, alg.Compare(a, b))
+		}
+	}
+
+	var scale float64
+	for _, scale = range latticeScales {
+		integer := true
+		for _, s := range scores {
+			integer = integer && math.Abs(s*scale-math.Round(s*scale)) < 1e-9
+		}
+		if integer {
+			break
+		}
+	}
+	ints := make([]int, len(scores))
+	gcd, low, high := 0, math.MaxInt32, math.MinInt32
+	for i, s := range scores {
+		if math.IsInf(s, 0) || math.IsNaN(s) || math.Abs(s*scale) > 1e6 {
+			return nil, errors.Wrapf(ErrNoStatistics, "score %v", s)
+		}
+		ints[i] = int(math.Round(s * scale))
+		gcd = gcdInt(gcd, absInt(ints[i]))
+	}
+	if gcd == 0 {
+		return nil, errors.Wrap(ErrNoStatistics, "all scores are 0")
+	}
+	for i := range ints {
+		ints[i] /= gcd
+		low, high = minInt(low, ints[i]), maxInt(high, ints[i])
+	}
+
+	sl := &scoreLattice{low: low, probs: make([]float64, high-low+1), unit: float64(gcd) / scale}
+	expected := float64(0)
+	for i := range ints {
+		p := freqs[i/len(res)] * freqs[i%len(res)]
+		sl.probs[ints[i]-low] += p
+		expected += p * float64(ints[i])
+	}
+	if expected >= 0 {
+		return nil, errors.Wrapf(ErrNoStatistics, "expected score %g is not negative", expected*sl.unit)
+	}
+	if high <= 0 {
+		return nil, errors.Wrap(ErrNoStatistics, "no positive score")
+	}
+	return sl, nil
+}
+
+func gcdInt(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func (sl *scoreLattice) high() int {
+	return sl.low + len(sl.probs) - 1
+}
+
+// moment returns sum of p(s)*exp(lambda*s) - 1, root of it is lambda
+func (sl *scoreLattice) moment(lambda float64) float64 {
+	sum := float64(-1)
+	for i, p := range sl.probs {
+		sum += p * math.Exp(lambda*float64(sl.low+i))
+	}
+	return sum
+}
+
+// lambda finds positive root of moment by bisection
+func (sl *scoreLattice) lambda() float64 {
+	lo, hi := float64(0), 0.5
+	for sl.moment(hi) < 0 {
+		lo, hi = hi, 2*hi
+	}
+	for n := 0; n < 200 && hi-lo > 1e-15*hi; n++ {
+		mid := (lo + hi) / 2
+		if sl.moment(mid) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func (sl *scoreLattice) entropy(lambda float64) float64 {
+	sum := float64(0)
+	for i, p := range sl.probs {
+		s := float64(sl.low + i)
+		sum += p * s * math.Exp(lambda*s)
+	}
+	return lambda * sum
+}
+
+// k calculates K by Karlin & Altschul (1990) series for lattice scores:
+// K = lambda*exp(-2*sigma) / (H*(1-exp(-lambda))), sigma = sum of (E[exp(lambda*S_k); S_k<0] + P(S_k>=0)) / k,
+// S_k is sum of k random pair scores
+func (sl *scoreLattice) k(lambda, h float64) float64 {
+	const (
+		maxIter  = 100
+		sumLimit = 1e-10
+	)
+	low, high := sl.low, sl.high()
+	// dist[s-distLow] is P(S_k = s)
+	dist, distLow := []float64{1}, 0
+	sigma := float64(0)
+	for k := 1; k <= maxIter; k++ {
+		next := make([]float64, len(dist)+high-low)
+		for i, p := range dist {
+			if p == 0 {
+				continue
+			}
+			for j, q := range sl.probs {
+				next[i+j] += p * q
+			}
+		}
+		dist, distLow = next, distLow+low
+		term := float64(0)
+		for i, p := range dist {
+			s := distLow + i
+			if s < 0 {
+				term += p * math.Exp(lambda*float64(s))
+			} else {
+				term += p
+			}
+		}
+		sigma += term / float64(k)
+		if term < sumLimit {
+			break
+		}
+	}
+	return lambda * math.Exp(-2*sigma) / (h * (1 - math.Exp(-lambda)))
+}
+
+// UngappedParams calculates Karlin-Altschul parameters of allignments without gaps scored by alg
+// for random sequences with residue frequencies bg. Scores are rounded to a lattice if they are not integer.
+func UngappedParams(alg Alligner, bg Background) (KarlinParams, error) {
+	sl, err := newScoreLattice(alg, bg)
+	if err != nil {
+		return KarlinParams{}, err
+	}
+	lambda := sl.lambda()
+	h := sl.entropy(lambda)
+	return KarlinParams{
+		Lambda: lambda / sl.unit,
+		K:      sl.k(lambda, h),
+		H:      h,
+	}, nil
+}
+
+// gappedParams are parameters of BLAST for gap cost open + extend*length
+type gappedParams struct {
+	open, extend float64
+	KarlinParams
+}
+
+// blastGapped are pre-fitted parameters of gapped allignments from BLAST (blast_stat.c),
+// costs are in BLAST convention
+var blastGapped = map[string][]gappedParams{
+	"BLOSUM45": {
+		{13, 3, KarlinParams{0.207, 0.049, 0.14}}, {12, 3, KarlinParams{0.199, 0.039, 0.11}},
+		{11, 3, KarlinParams{0.190, 0.031, 0.095}}, {10, 3, KarlinParams{0.179, 0.023, 0.075}},
+		{16, 2, KarlinParams{0.210, 0.051, 0.14}}, {15, 2, KarlinParams{0.203, 0.041, 0.12}},
+		{14, 2, KarlinParams{0.195, 0.032, 0.10}}, {13, 2, KarlinParams{0.185, 0.024, 0.084}},
+		{12, 2, KarlinParams{0.171, 0.016, 0.061}}, {19, 1, KarlinParams{0.205, 0.040, 0.11}},
+		{18, 1, KarlinParams{0.198, 0.032, 0.10}}, {17, 1, KarlinParams{0.189, 0.024, 0.079}},
+		{16, 1, KarlinParams{0.176, 0.016, 0.063}},
+	},
+	"BLOSUM50": {
+		{13, 3, KarlinParams{0.212, 0.063, 0.19}}, {12, 3, KarlinParams{0.206, 0.055, 0.17}},
+		{11, 3, KarlinParams{0.197, 0.042, 0.14}}, {10, 3, KarlinParams{0.186, 0.031, 0.11}},
+		{9, 3, KarlinParams{0.172, 0.022, 0.082}}, {16, 2, KarlinParams{0.215, 0.066, 0.20}},
+		{15, 2, KarlinParams{0.210, 0.058, 0.17}}, {14, 2, KarlinParams{0.202, 0.045, 0.14}},
+		{13, 2, KarlinParams{0.193, 0.035, 0.12}}, {12, 2, KarlinParams{0.181, 0.025, 0.095}},
+		{19, 1, KarlinParams{0.212, 0.057, 0.18}}, {18, 1, KarlinParams{0.207, 0.050, 0.15}},
+		{17, 1, KarlinParams{0.198, 0.037, 0.12}}, {16, 1, KarlinParams{0.186, 0.025, 0.10}},
+		{15, 1, KarlinParams{0.171, 0.015, 0.063}},
+	},
+	"BLOSUM62": {
+		{11, 2, KarlinParams{0.297, 0.082, 0.27}}, {10, 2, KarlinParams{0.291, 0.075, 0.23}},
+		{9, 2, KarlinParams{0.279, 0.058, 0.19}}, {8, 2, KarlinParams{0.264, 0.045, 0.15}},
+		{7, 2, KarlinParams{0.239, 0.027, 0.10}}, {6, 2, KarlinParams{0.201, 0.012, 0.061}},
+		{13, 1, KarlinParams{0.292, 0.071, 0.23}}, {12, 1, KarlinParams{0.283, 0.059, 0.19}},
+		{11, 1, KarlinParams{0.267, 0.041, 0.14}}, {10, 1, KarlinParams{0.243, 0.024, 0.10}},
+		{9, 1, KarlinParams{0.206, 0.010, 0.052}},
+	},
+	"BLOSUM80": {
+		{25, 2, KarlinParams{0.342, 0.17, 0.66}}, {13, 2, KarlinParams{0.336, 0.15, 0.57}},
+		{9, 2, KarlinParams{0.319, 0.11, 0.42}}, {8, 2, KarlinParams{0.308, 0.090, 0.35}},
+		{7, 2, KarlinParams{0.293, 0.070, 0.27}}, {6, 2, KarlinParams{0.268, 0.045, 0.19}},
+		{11, 1, KarlinParams{0.314, 0.095, 0.35}}, {10, 1, KarlinParams{0.299, 0.071, 0.27}},
+		{9, 1, KarlinParams{0.279, 0.048, 0.20}},
+	},
+	"BLOSUM90": {
+		{9, 2, KarlinParams{0.310, 0.12, 0.46}}, {8, 2, KarlinParams{0.300, 0.099, 0.39}},
+		{7, 2, KarlinParams{0.283, 0.072, 0.30}}, {6, 2, KarlinParams{0.259, 0.048, 0.22}},
+		{11, 1, KarlinParams{0.302, 0.093, 0.39}}, {10, 1, KarlinParams{0.290, 0.075, 0.28}},
+		{9, 1, KarlinParams{0.265, 0.044, 0.20}},
+	},
+	"PAM30": {
+		{7, 2, KarlinParams{0.305, 0.15, 0.87}}, {6, 2, KarlinParams{0.287, 0.11, 0.68}},
+		{5, 2, KarlinParams{0.264, 0.079, 0.45}}, {10, 1, KarlinParams{0.309, 0.15, 0.88}},
+		{9, 1, KarlinParams{0.294, 0.11, 0.61}}, {8, 1, KarlinParams{0.270, 0.072, 0.40}},
+	},
+	"PAM70": {
+		{8, 2, KarlinParams{0.301, 0.12, 0.54}}, {7, 2, KarlinParams{0.286, 0.093, 0.43}},
+		{6, 2, KarlinParams{0.264, 0.064, 0.29}}, {11, 1, KarlinParams{0.305, 0.12, 0.52}},
+		{10, 1, KarlinParams{0.291, 0.091, 0.41}}, {9, 1, KarlinParams{0.270, 0.060, 0.28}},
+	},
+	"PAM250": {
+		{15, 3, KarlinParams{0.205, 0.049, 0.13}}, {14, 3, KarlinParams{0.200, 0.043, 0.12}},
+		{13, 3, KarlinParams{0.194, 0.036, 0.10}}, {12, 3, KarlinParams{0.186, 0.029, 0.085}},
+		{11, 3, KarlinParams{0.174, 0.020, 0.070}}, {17, 2, KarlinParams{0.204, 0.047, 0.12}},
+		{16, 2, KarlinParams{0.198, 0.038, 0.11}}, {15, 2, KarlinParams{0.191, 0.031, 0.087}},
+		{14, 2, KarlinParams{0.182, 0.024, 0.073}}, {13, 2, KarlinParams{0.171, 0.017, 0.059}},
+		{21, 1, KarlinParams{0.205, 0.045, 0.11}}, {20, 1, KarlinParams{0.199, 0.037, 0.10}},
+		{19, 1, KarlinParams{0.192, 0.029, 0.083}}, {18, 1, KarlinParams{0.183, 0.021, 0.070}},
+		{17, 1, KarlinParams{0.171, 0.014, 0.052}},
+	},
+}
+
+// GappedParams returns pre-fitted parameters of local allignment with gaps for built-in matrix
+// (name is case insensitive, BLOSUM64 is BLOSUM62) and gap penalties of Alligner: gap of length l
+// costs gapOpen + (l-1)*gapExtend, e.g. BLAST costs 11/1 are gapOpen -12 and gapExtend -1.
+// False is returned for settings without pre-fitted parameters.
+func GappedParams(matrix string, gapOpen, gapExtend float64) (KarlinParams, bool) {
+	name := strings.ToUpper(matrix)
+	if name == "BLOSUM64" {
+		name = "BLOSUM62"
+	}
+	for _, p := range blastGapped[name] {
+		if -gapExtend == p.extend && -gapOpen == p.open+p.extend {
+			return p.KarlinParams, true
+		}
+	}
+	return KarlinParams{}, false
+}
@@ -0,0 +1,97 @@
+package sequence
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUngappedParams(t *testing.T) {
+	// scores +1/-1 have closed form lambda = ln(q/p), K = (q-p)^2/q
+	p, err := UngappedParams(NewDefaultExteded(-1, -1), UniformBackground("ACGT"))
+	require.NoError(t, err)
+	require.InDelta(t, math.Log(3), p.Lambda, 1e-9)
+	require.InDelta(t, 0.25/0.75, p.K, 1e-6)
+
+	// BLAST values
+	for _, tc := range []struct {
+		matrix    string
+		lambda, k float64
+	}{
+		{"BLOSUM62", 0.3176, 0.134},
+		{"BLOSUM45", 0.2291, 0.0924},
+		{"BLOSUM80", 0.3430, 0.177},
+		{"PAM250", 0.2252, 0.0868},
+	} {
+		allg, err := MatrixByName(tc.matrix, -11, -1)
+		require.NoError(t, err)
+		p, err := UngappedParams(allg, RobinsonFrequencies)
+		require.NoError(t, err)
+		require.InDelta(t, tc.lambda, p.Lambda, 0.0005, tc.matrix)
+		require.InDelta(t, tc.k, p.K, 0.002, tc.matrix)
+	}
+
+	// the same scores in other units have the same K and scaled lambda
+	p, err = UngappedParams(NewAlligerBLOSUM62(-11, -1), RobinsonFrequencies)
+	require.NoError(t, err)
+	scaled, err := UngappedParams(NewMaskedAlligner(NewAlligerBLOSUM62(-11, -1), nil, nil, 0.5), RobinsonFrequencies)
+	require.NoError(t, err)
+	require.InDelta(t, p.Lambda, scaled.Lambda, 1e-9)
+	require.InDelta(t, p.K, scaled.K, 1e-9)
+
+	_, err = UngappedParams(NewAlligerBLOSUM62(-11, -1), Background{'W': 1})
+	require.Equal(t, ErrNoStatistics, errors.Cause(err))
+	_, err = UngappedParams(NewAlligerBLOSUM62(-11, -1), Background{'W': 1, 'C': 1, 'L': 1})
+	require.Equal(t, ErrNoStatistics, errors.Cause(err))
+}
+
+func TestBitScoreEValue(t *testing.T) {
+	p, ok := GappedParams("blosum62", -12, -1)
+	require.True(t, ok)
+	require.Equal(t, KarlinParams{Lambda: 0.267, K: 0.041, H: 0.14}, p)
+	require.InDelta(t, (0.267*100-math.Log(0.041))/math.Ln2, p.BitScore(100), 1e-9)
+	require.InDelta(t, 0.041*300*1e6*math.Exp(-26.7), p.EValue(100, 300, 1000000), 1e-12)
+
+	_, ok = GappedParams("BLOSUM62", -11, -1)
+	require.True(t, ok)
+	_, ok = GappedParams("BLOSUM62", -2, -2)
+	require.False(t, ok)
+	_, ok = GappedParams("NUC.4.4", -12, -1)
+	require.False(t, ok)
+}
+
+func TestFitGumbel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	exp := Gumbel{Mu: 30, Lambda: 0.25}
+	scores := make([]float64, 5000)
+	for i := range scores {
+		scores[i] = exp.Mu - math.Log(-math.Log(r.Float64()))/exp.Lambda
+	}
+	g, err := FitGumbel(scores)
+	require.NoError(t, err)
+	require.InDelta(t, exp.Mu, g.Mu, 0.3)
+	require.InDelta(t, exp.Lambda, g.Lambda, 0.01)
+	require.InDelta(t, 1-math.Exp(-1), g.PValue(g.Mu), 1e-12)
+
+	_, err = FitGumbel([]float64{1, 1, 1})
+	require.Error(t, err)
+	// mean of equal scores is not exact
+	_, err = FitGumbel([]float64{0.1, 0.1, 0.1})
+	require.Error(t, err)
+}
+
+func TestEstimateParams(t *testing.T) {
+	allg := NewAlligerBLOSUM62(-12, -1)
+	p, err := EstimateParams(allg, RobinsonFrequencies, EstimateOptions{Pairs: 200, Length: 200, Seed: 1})
+	require.NoError(t, err)
+	exp, _ := GappedParams("BLOSUM62", -12, -1)
+	require.InDelta(t, exp.Lambda, p.Lambda, 0.07)
+	require.Greater(t, p.K, float64(0))
+
+	same, err := EstimateParams(allg, RobinsonFrequencies, EstimateOptions{Pairs: 200, Length: 200, Seed: 1})
+	require.NoError(t, err)
+	require.Equal(t, p, same)
+}
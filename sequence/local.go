@@ -0,0 +1,121 @@
+package sequence
+
+import "strings"
+
+func (dt *allgDinTable) initLocal(alg Alligner, a, b string) {
+	for i := 1; i <= len(a); i++ {
+		dt.vals[i][0] = 0
+		dt.acts[i][0] = actionStop
+	}
+	for j := 1; j <= len(b); j++ {
+		dt.vals[0][j] = 0
+		dt.acts[0][j] = actionStop
+	}
+	dt.calcImpl = dt.calcLocal
+}
+
+func (dt *allgDinTable) calcLocal(alg Alligner, i, j int, a, b byte) {
+	dt.calc(alg, i, j, a, b)
+	if dt.vals[i][j] <= 0 {
+		dt.vals[i][j] = 0
+		dt.acts[i][j] = actionStop
+	}
+}
+
+func (dt *allgDinTable) initLocalExtend(alg Alligner, a, b string) {
+	dt.initExtend(alg, a, b)
+	inf := dt.inss[0][0]
+	for i := 1; i <= len(a); i++ {
+		dt.dels[i][0] = inf
+		dt.acts[i][0] = actionStop
+	}
+	for j := 1; j <= len(b); j++ {
+		dt.inss[0][j] = inf
+		dt.acts[0][j] = actionStop
+	}
+	dt.calcImpl = dt.calcLocalExtend
+}
+
+// calcLocalExtend is calcExtend with an option to start new allignment
+// from the current match instead of continuing a negative one
+func (dt *allgDinTable) calcLocalExtend(alg Alligner, i, j int, a, b byte) {
+	dt.calcExtend(alg, i, j, a, b)
+	cmp := dt.cmp(i-1, a, j-1, b)
+	if dt.vals[i][j] <= cmp {
+		dt.vals[i][j] = cmp
+		dt.acts[i][j] &^= dirMask << shiftMat
+	}
+}
+
+// maxCell returns the first cell with the best score.
+// In extended table only match state is checked, since local allignment never ends with gap.
+func (dt *allgDinTable) maxCell() (int, int) {
+	mi, mj := 0, 0
+	for i := range dt.vals {
+		for j := range dt.vals[i] {
+			if dt.vals[i][j] > dt.vals[mi][mj] {
+				mi, mj = i, j
+			}
+		}
+	}
+	return mi, mj
+}
+
+func (dt allgDinTable) allignLocal(alg Alligner, a, b string, i, j int) (string, string, int, int) {
+	resA := strings.Builder{}
+	resB := strings.Builder{}
+	for dt.acts[i][j] != actionStop {
+		switch dt.acts[i][j] {
+		case actionUp:
+			i--
+			resA.WriteByte(a[i])
+			resB.WriteByte(alg.Gap())
+		case actionLeft:
+			j--
+			resA.WriteByte(alg.Gap())
+			resB.WriteByte(b[j])
+		case actionUpLeft:
+			i--
+			j--
+			resA.WriteByte(a[i])
+			resB.WriteByte(b[j])
+		}
+	}
+	return reverse(resA.String()), reverse(resB.String()), i, j
+}
+
+func (dt allgDinTable) allignLocalExtend(alg Alligner, a, b string, i, j int) (string, string, int, int) {
+	resA := strings.Builder{}
+	resB := strings.Builder{}
+	dir := dirMat
+	if i == 0 || j == 0 {
+		dir = actionStop
+	}
+	for dir != actionStop {
+		n := dt.acts[i][j]
+		switch dir {
+		case dirDel:
+			i--
+			resA.WriteByte(a[i])
+			resB.WriteByte(alg.Gap())
+		case dirIns:
+			j--
+			resA.WriteByte(alg.Gap())
+			resB.WriteByte(b[j])
+		case dirMat:
+			i--
+			j--
+			resA.WriteByte(a[i])
+			resB.WriteByte(b[j])
+		}
+		switch dir {
+		case dirMat:
+			dir = (n >> shiftMat) & dirMask
+		case dirDel:
+			dir = (n >> shiftDel) & dirMask
+		case dirIns:
+			dir = (n >> shiftIns) & dirMask
+		}
+	}
+	return reverse(resA.String()), reverse(resB.String()), i, j
+}
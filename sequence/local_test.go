@@ -0,0 +1,173 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCaseLocal struct {
+	a     string
+	b     string
+	resA  string
+	resB  string
+	score float64
+	begA  int
+	endA  int
+	begB  int
+	endB  int
+}
+
+func runLocalTestCases(t *testing.T, allg Alligner, tcs []testCaseLocal) {
+	for _, threads := range []int{1, 8} {
+		for i, tc := range tcs {
+			res, err := AllignWithOptions(allg, tc.a, tc.b, Options{Mode: ModeLocal, Threads: threads})
+			require.NoError(t, err)
+			require.Equal(t, res.Score, checkScore(allg, res.ResA, res.ResB), "incosistent result on\n%s\n%s", res.ResA, res.ResB)
+			require.Equal(t, tc.resA, res.ResA, "failed seq A test %d", i)
+			require.Equal(t, tc.resB, res.ResB, "failed seq B test %d", i)
+			require.Equal(t, tc.score, res.Score, "failed SCORE test %d", i)
+			require.Equal(t, []int{tc.begA, tc.endA, tc.begB, tc.endB}, []int{res.BegA, res.EndA, res.BegB, res.EndB}, "failed region test %d", i)
+		}
+	}
+}
+
+func TestAllgLocal(t *testing.T) {
+	allg := testAlligner()
+	tcs := []testCaseLocal{
+		{
+			a: "",
+			b: "",
+		},
+		{
+			a: "ABCD",
+			b: "",
+		},
+		{
+			a: "A",
+			b: "B",
+		},
+		{
+			a:     "BBAAAB",
+			b:     "CAAAD",
+			resA:  "AAA",
+			resB:  "AAA",
+			score: 15,
+			begA:  2,
+			endA:  5,
+			begB:  1,
+			endB:  4,
+		},
+		{
+			a:     "DDDAAAADDD",
+			b:     "CCCAAAACCC",
+			resA:  "AAAA",
+			resB:  "AAAA",
+			score: 20,
+			begA:  3,
+			endA:  7,
+			begB:  3,
+			endB:  7,
+		},
+		{
+			a:     "AABAA",
+			b:     "CAACAAC",
+			resA:  "AABAA",
+			resB:  "AACAA",
+			score: 16,
+			begA:  0,
+			endA:  5,
+			begB:  1,
+			endB:  6,
+		},
+		{
+			a:     "AAAABBBB",
+			b:     "DAAAACBBBBD",
+			resA:  "AAAA-BBBB",
+			resB:  "AAAACBBBB",
+			score: 35,
+			begA:  0,
+			endA:  8,
+			begB:  1,
+			endB:  10,
+		},
+	}
+	runLocalTestCases(t, allg, tcs)
+}
+
+func TestAllgLocalExt(t *testing.T) {
+	allg := NewTableAlliger(-6, -1, testAlligner().(*tableAlliger).table, testAlligner().(*tableAlliger).byteToIdx)
+	tcs := []testCaseLocal{
+		{
+			a: "",
+			b: "",
+		},
+		{
+			a: "A",
+			b: "B",
+		},
+		{
+			a:     "CAAAAC",
+			b:     "DAAAAD",
+			resA:  "AAAA",
+			resB:  "AAAA",
+			score: 20,
+			begA:  1,
+			endA:  5,
+			begB:  1,
+			endB:  5,
+		},
+		{
+			a:     "DAAAABBCCAAAAD",
+			b:     "CCAAAAAAAACC",
+			resA:  "AAAABBCCAAAA",
+			resB:  "AAAA----AAAA",
+			score: 31,
+			begA:  1,
+			endA:  13,
+			begB:  2,
+			endB:  10,
+		},
+		{
+			a:     "AAAABBBBBBBBBBBBBBBBBBBBAAAA",
+			b:     "AAAAAAAA",
+			resA:  "AAAA",
+			resB:  "AAAA",
+			score: 20,
+			begA:  0,
+			endA:  4,
+			begB:  0,
+			endB:  4,
+		},
+	}
+	runLocalTestCases(t, allg, tcs)
+}
+
+func TestAllgLocalBlosum(t *testing.T) {
+	allg := NewAlligerBLOSUM62(-10, -1)
+	a := "PSPETVIHSGWVIWRELFSHWPDQCKLLFGDWFAWIHWTYLVYYSAGPPCQGQSDIVVMMQKKLRTNFCQCYKYWYQ"
+	b := "PSPSDQFFTVIHSCLYWVIWRDLMSHLFMNGAAIDIHWTWDSIAIGPPLVYPIEEVFAGPSTIVVMMQKMLRTNFCQCYKPWYQ"
+	glob, err := AllignWithOptions(allg, a, b, Options{})
+	require.NoError(t, err)
+	res, err := AllignWithOptions(allg, a, b, Options{Mode: ModeLocal, Threads: 4})
+	require.NoError(t, err)
+	require.True(t, res.Score >= glob.Score)
+	require.Equal(t, res.Score, checkScore(allg, res.ResA, res.ResB))
+	require.Equal(t, a[res.BegA:res.EndA], removeGaps(allg, res.ResA))
+	require.Equal(t, b[res.BegB:res.EndB], removeGaps(allg, res.ResB))
+}
+
+func TestAllgLocalMemOpt(t *testing.T) {
+	_, err := AllignWithOptions(testAlligner(), "AB", "AB", Options{Mode: ModeLocal, MemoryOpt: true})
+	require.Error(t, err)
+}
+
+func removeGaps(allg Alligner, s string) string {
+	res := make([]byte, 0, len(s))
+	for i := range s {
+		if s[i] != allg.Gap() {
+			res = append(res, s[i])
+		}
+	}
+	return string(res)
+}
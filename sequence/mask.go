@@ -0,0 +1,29 @@
+package sequence
+
+type maskedAlligner struct {
+	Alligner
+	cmp    compareFunc
+	maskA  []bool
+	maskB  []bool
+	weight float64
+}
+
+// NewMaskedAlligner returns Alligner which multiplies scores of pairs with a soft-masked residue by weight.
+// maskA and maskB mark masked positions of the first and the second sequence, nil means nothing is masked.
+func NewMaskedAlligner(alg Alligner, maskA, maskB []bool, weight float64) PositionalAlligner {
+	return &maskedAlligner{
+		Alligner: alg,
+		cmp:      newCompareFunc(alg),
+		maskA:    maskA,
+		maskB:    maskB,
+		weight:   weight,
+	}
+}
+
+func (m *maskedAlligner) CompareAt(i int, a byte, j int, b byte) float64 {
+	v := m.cmp(i, a, j, b)
+	if (i < len(m.maskA) && m.maskA[i]) || (j < len(m.maskB) && m.maskB[j]) {
+		v *= m.weight
+	}
+	return v
+}
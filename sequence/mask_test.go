@@ -0,0 +1,26 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskedAlligner(t *testing.T) {
+	mask := []bool{false, false, true, true, false, false}
+	for _, allg := range []Alligner{NewAlligerDNA(-10, -10), NewAlligerDNA(-10, -1)} {
+		masked := NewMaskedAlligner(allg, mask, nil, 0.5)
+		require.Equal(t, float64(5), masked.CompareAt(1, 'A', 1, 'A'))
+		require.Equal(t, 2.5, masked.CompareAt(2, 'A', 0, 'A'))
+		require.Equal(t, float64(-2), masked.CompareAt(3, 'A', 7, 'T'))
+
+		for _, opts := range []Options{{}, {MemoryOpt: true}, {Mode: ModeLocal}} {
+			res, err := AllignWithOptions(masked, "ACGTAC", "ACGTAC", opts)
+			require.NoError(t, err)
+			require.Equal(t, float64(25), res.Score)
+			score, err := Score(masked, "ACGTAC", "ACGTAC", opts)
+			require.NoError(t, err)
+			require.Equal(t, float64(25), score)
+		}
+	}
+}
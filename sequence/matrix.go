@@ -0,0 +1,93 @@
+package sequence
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadMatrix is returned when substitution matrix can not be parsed
+var ErrBadMatrix = errors.New("bad matrix")
+
+// ParseMatrix reads substitution matrix in NCBI/EMBOSS text format:
+// lines starting with '#' are comments, first line is a header with residue symbols,
+// it is followed by a row for every symbol with the symbol itself and scores.
+func ParseMatrix(r io.Reader, gapOpen, gapExtend float64) (Alligner, error) {
+	table, byteToIdx, err := parseMatrix(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewTableAlliger(gapOpen, gapExtend, table, byteToIdx), nil
+}
+
+func parseMatrix(r io.Reader) ([][]float64, map[byte]int, error) {
+	var header []byte
+	var table [][]float64
+	var seen []bool
+	byteToIdx := make(map[byte]int)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(text)
+		if header == nil {
+			for _, f := range fields {
+				if len(f) != 1 {
+					return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: bad symbol %q in header", line, f)
+				}
+				if _, ok := byteToIdx[f[0]]; ok {
+					return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: duplicated symbol %q", line, f)
+				}
+				byteToIdx[f[0]] = len(header)
+				header = append(header, f[0])
+			}
+			table = make([][]float64, len(header))
+			seen = make([]bool, len(header))
+			continue
+		}
+
+		if len(fields[0]) != 1 {
+			return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: bad row symbol %q", line, fields[0])
+		}
+		idx, ok := byteToIdx[fields[0][0]]
+		if !ok {
+			return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: row symbol %q is not in header", line, fields[0])
+		}
+		if seen[idx] {
+			return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: duplicated row %q", line, fields[0])
+		}
+		if len(fields)-1 != len(header) {
+			return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: expected %d scores, got %d", line, len(header), len(fields)-1)
+		}
+		row := make([]float64, len(header))
+		for i, f := range fields[1:] {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, nil, errors.Wrapf(ErrBadMatrix, "line %d: bad score %q", line, f)
+			}
+			row[i] = v
+		}
+		table[idx] = row
+		seen[idx] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.Wrap(ErrBadMatrix, "no header")
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, nil, errors.Wrapf(ErrBadMatrix, "no row for symbol %q", header[i])
+		}
+	}
+	return table, byteToIdx, nil
+}
@@ -0,0 +1,137 @@
+package sequence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const testBlosum62 = `#  Matrix made by matblas from blosum62.iij
+#  * column uses minimum score
+#  BLOSUM Clustered Scoring Matrix in 1/2 Bit Units
+#  Blocks Database = /data/blocks_5.0/blocks.dat
+#  Cluster Percentage: >= 62
+#  Entropy =   0.6979, Expected =  -0.5209
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  4 -1 -2 -2  0 -1 -1  0 -2 -1 -1 -1 -1 -2 -1  1  0 -3 -2  0 -2 -1  0 -4
+R -1  5  0 -2 -3  1  0 -2  0 -3 -2  2 -1 -3 -2 -1 -1 -3 -2 -3 -1  0 -1 -4
+N -2  0  6  1 -3  0  0  0  1 -3 -3  0 -2 -3 -2  1  0 -4 -2 -3  3  0 -1 -4
+D -2 -2  1  6 -3  0  2 -1 -1 -3 -4 -1 -3 -3 -1  0 -1 -4 -3 -3  4  1 -1 -4
+C  0 -3 -3 -3  9 -3 -4 -3 -3 -1 -1 -3 -1 -2 -3 -1 -1 -2 -2 -1 -3 -3 -2 -4
+Q -1  1  0  0 -3  5  2 -2  0 -3 -2  1  0 -3 -1  0 -1 -2 -1 -2  0  3 -1 -4
+E -1  0  0  2 -4  2  5 -2  0 -3 -3  1 -2 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+G  0 -2  0 -1 -3 -2 -2  6 -2 -4 -4 -2 -3 -3 -2  0 -2 -2 -3 -3 -1 -2 -1 -4
+H -2  0  1 -1 -3  0  0 -2  8 -3 -3 -1 -2 -1 -2 -1 -2 -2  2 -3  0  0 -1 -4
+I -1 -3 -3 -3 -1 -3 -3 -4 -3  4  2 -3  1  0 -3 -2 -1 -3 -1  3 -3 -3 -1 -4
+L -1 -2 -3 -4 -1 -2 -3 -4 -3  2  4 -2  2  0 -3 -2 -1 -2 -1  1 -4 -3 -1 -4
+K -1  2  0 -1 -3  1  1 -2 -1 -3 -2  5 -1 -3 -1  0 -1 -3 -2 -2  0  1 -1 -4
+M -1 -1 -2 -3 -1  0 -2 -3 -2  1  2 -1  5  0 -2 -1 -1 -1 -1  1 -3 -1 -1 -4
+F -2 -3 -3 -3 -2 -3 -3 -3 -1  0  0 -3  0  6 -4 -2 -2  1  3 -1 -3 -3 -1 -4
+P -1 -2 -2 -1 -3 -1 -1 -2 -2 -3 -3 -1 -2 -4  7 -1 -1 -4 -3 -2 -2 -1 -2 -4
+S  1 -1  1  0 -1  0  0  0 -1 -2 -2  0 -1 -2 -1  4  1 -3 -2 -2  0  0  0 -4
+T  0 -1  0 -1 -1 -1 -1 -2 -2 -1 -1 -1 -1 -2 -1  1  5 -2 -2  0 -1 -1  0 -4
+W -3 -3 -4 -4 -2 -2 -3 -2 -2 -3 -2 -3 -1  1 -4 -3 -2 11  2 -3 -4 -3 -2 -4
+Y -2 -2 -2 -3 -2 -1 -2 -3  2 -1 -1 -2 -1  3 -3 -2 -2  2  7 -1 -3 -2 -1 -4
+V  0 -3 -3 -3 -1 -2 -2 -3 -3  3  1 -2  1 -1 -2 -2  0 -3 -1  4 -3 -2 -1 -4
+B -2 -1  3  4 -3  0  1 -1  0 -3 -4  0 -3 -3 -2  0 -1 -4 -3 -3  4  1 -1 -4
+Z -1  0  0  1 -3  3  4 -2  0 -3 -3  1 -1 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+X  0 -1 -1 -1 -2 -1 -1 -1 -1 -1 -1 -1 -1 -1 -2  0  0 -2 -1 -1 -1 -1 -1 -4
+* -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4  1
+`
+
+func TestParseMatrix(t *testing.T) {
+	allg, err := ParseMatrix(strings.NewReader(testBlosum62), -10, -1)
+	require.NoError(t, err)
+	require.True(t, allg.IsExtended())
+	require.Equal(t, float64(-10), allg.GapOpen())
+	require.Equal(t, float64(-1), allg.GapExtend())
+
+	blosum := NewAlligerBLOSUM62(-10, -1)
+	residues := "ARNDCQEGHILKMFPSTWYV"
+	for i := range residues {
+		for j := range residues {
+			require.Equal(t, blosum.Compare(residues[i], residues[j]), allg.Compare(residues[i], residues[j]),
+				"%c %c", residues[i], residues[j])
+		}
+	}
+	for _, b := range []byte("BZX*") {
+		require.True(t, allg.InAlphabet(b))
+	}
+	require.Equal(t, float64(4), allg.Compare('D', 'B'))
+	require.Equal(t, float64(-4), allg.Compare('*', 'W'))
+	require.Equal(t, float64(1), allg.Compare('*', '*'))
+	require.False(t, allg.InAlphabet('J'))
+}
+
+func TestParseMatrixUnordered(t *testing.T) {
+	allg, err := ParseMatrix(strings.NewReader(`
+# rows are not in header order
+     A    C
+C   -1.5  2
+A    1   -1.5
+`), -2, -2)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), allg.Compare('A', 'A'))
+	require.Equal(t, float64(2), allg.Compare('C', 'C'))
+	require.Equal(t, -1.5, allg.Compare('A', 'C'))
+}
+
+func TestParseMatrixErrors(t *testing.T) {
+	tcs := []string{
+		"",
+		"# only comments\n",
+		"A C\nA 1 -1\n",
+		"A C\nA 1 -1\nC -1 1\nA 1 -1\n",
+		"A C\nA 1 -1\nC -1\n",
+		"A C\nA 1 -1\nC -1 x\n",
+		"A C\nA 1 -1\nG -1 1\n",
+		"A AC\nA 1 -1\n",
+		"A A\nA 1 -1\n",
+	}
+	for i, tc := range tcs {
+		_, err := ParseMatrix(strings.NewReader(tc), -1, -1)
+		require.Error(t, err, "test %d", i)
+		require.Equal(t, ErrBadMatrix, errors.Cause(err), "test %d", i)
+	}
+}
+
+func TestMatrixByName(t *testing.T) {
+	for _, name := range MatrixNames() {
+		allg, err := MatrixByName(strings.ToLower(name), -10, -1)
+		require.NoError(t, err, name)
+		table := allg.(*tableAlliger)
+		for i := range table.table {
+			for j := range table.table {
+				require.Equal(t, table.table[i][j], table.table[j][i], "%s is not symmetric", name)
+			}
+		}
+	}
+
+	parsed, err := ParseMatrix(strings.NewReader(testBlosum62), -10, -1)
+	require.NoError(t, err)
+	allg, err := MatrixByName("Blosum62", -10, -1)
+	require.NoError(t, err)
+	symbols := "ARNDCQEGHILKMFPSTWYVBZX*"
+	for i := range symbols {
+		for j := range symbols {
+			require.Equal(t, parsed.Compare(symbols[i], symbols[j]), allg.Compare(symbols[i], symbols[j]))
+		}
+	}
+
+	allg, err = MatrixByName("PAM250", -10, -1)
+	require.NoError(t, err)
+	require.Equal(t, float64(17), allg.Compare('W', 'W'))
+	require.Equal(t, float64(-8), allg.Compare('*', 'A'))
+
+	allg, err = MatrixByName("NUC.4.4", -10, -1)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), allg.Compare('A', 'R'))
+	require.Equal(t, float64(-4), allg.Compare('A', 'Y'))
+
+	_, err = MatrixByName("BLOSUM1000", -10, -1)
+	require.Equal(t, ErrUnknownMatrix, errors.Cause(err))
+	_, err = MatrixByName("pam120", -10, -1)
+	require.Equal(t, ErrMatrixNotBundled, errors.Cause(err))
+}
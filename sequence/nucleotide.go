@@ -0,0 +1,80 @@
+package sequence
+
+// iupacBases are bases matched by nucleotide symbols, U is treated as T
+var iupacBases = map[byte]string{
+	'A': "A",
+	'C': "C",
+	'G': "G",
+	'T': "T",
+	'U': "T",
+	'R': "AG",
+	'Y': "CT",
+	'S': "CG",
+	'W': "AT",
+	'K': "GT",
+	'M': "AC",
+	'B': "CGT",
+	'D': "AGT",
+	'H': "ACT",
+	'V': "ACG",
+	'N': "ACGT",
+}
+
+// NucleotideScores are scores of pairs of bases
+type NucleotideScores struct {
+	Match float64
+	// Transition is a purine-purine (A-G) or pyrimidine-pyrimidine (C-T) mismatch
+	Transition float64
+	// Transversion is a purine-pyrimidine mismatch
+	Transversion float64
+}
+
+// DefaultNucleotideScores are the same scores as NewAlligerDNA uses
+var DefaultNucleotideScores = NucleotideScores{Match: 5, Transition: -4, Transversion: -4}
+
+func (s NucleotideScores) compareBases(a, b byte) float64 {
+	switch {
+	case a == b:
+		return s.Match
+	case isPurine(a) == isPurine(b):
+		return s.Transition
+	default:
+		return s.Transversion
+	}
+}
+
+func isPurine(b byte) bool {
+	return b == 'A' || b == 'G'
+}
+
+// NewAlligerNucleotide creates Alligner for DNA and RNA with IUPAC ambiguity codes.
+// Symbols are case insensitive, U is the same as T.
+// Pair of ambiguity codes is scored as average score of all pairs of bases they match,
+// e.g. with default scores A-R is (5 - 4) / 2.
+func NewAlligerNucleotide(gapOpen, gapExtend float64, scores NucleotideScores) Alligner {
+	symbols := make([]byte, 0, len(iupacBases))
+	for s := range iupacBases {
+		symbols = append(symbols, s)
+	}
+	byteToIdx := make(map[byte]int, 2*len(symbols))
+	for i, s := range symbols {
+		byteToIdx[s] = i
+		byteToIdx[s-'A'+'a'] = i
+	}
+	table := make([][]float64, len(symbols))
+	for i, a := range symbols {
+		table[i] = make([]float64, len(symbols))
+		basesA := iupacBases[a]
+		for j, b := range symbols {
+			basesB := iupacBases[b]
+			sum := float64(0)
+			for k := range basesA {
+				for l := range basesB {
+					sum += scores.compareBases(basesA[k], basesB[l])
+				}
+			}
+			table[i][j] = sum / float64(len(basesA)*len(basesB))
+		}
+	}
+	return NewTableAlliger(gapOpen, gapExtend, table, byteToIdx)
+}
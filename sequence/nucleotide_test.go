@@ -0,0 +1,49 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNucleotideCompare(t *testing.T) {
+	def := NewAlligerNucleotide(-10, -1, DefaultNucleotideScores)
+	dna := NewAlligerDNA(-10, -1)
+	bases := "ATGC"
+	for i := range bases {
+		for j := range bases {
+			require.Equal(t, dna.Compare(bases[i], bases[j]), def.Compare(bases[i], bases[j]))
+		}
+	}
+
+	allg := NewAlligerNucleotide(-10, -1, NucleotideScores{Match: 5, Transition: -1, Transversion: -4})
+	require.Equal(t, float64(-1), allg.Compare('A', 'G'))
+	require.Equal(t, float64(-1), allg.Compare('C', 'T'))
+	require.Equal(t, float64(-4), allg.Compare('A', 'T'))
+	require.Equal(t, float64(-4), allg.Compare('G', 'C'))
+
+	require.Equal(t, float64(5), allg.Compare('U', 'T'))
+	require.Equal(t, float64(5), allg.Compare('u', 'T'))
+	require.Equal(t, float64(5), allg.Compare('a', 'A'))
+	require.Equal(t, float64(2), allg.Compare('A', 'R'))
+	require.Equal(t, (5-1-4-4)/4.0, allg.Compare('N', 'A'))
+	require.Equal(t, allg.Compare('n', 'c'), allg.Compare('C', 'N'))
+	require.False(t, allg.InAlphabet('X'))
+	require.False(t, allg.InAlphabet('-'))
+}
+
+func TestNucleotideAllign(t *testing.T) {
+	allg := NewAlligerNucleotide(-10, -10, DefaultNucleotideScores)
+	res, err := AllignWithOptions(allg, "ACGUNacgt", "ACGTAACGT", Options{})
+	require.NoError(t, err)
+	require.Equal(t, "ACGUNacgt", res.ResA)
+	require.Equal(t, "ACGTAACGT", res.ResB)
+	require.Equal(t, 8*5+(5-4*3)/4.0, res.Score)
+
+	_, err = AllignWithOptions(allg, "ACGT", "ACXT", Options{})
+	require.Error(t, err)
+	require.Equal(t, ErrBadSeq, errors.Cause(err))
+	require.Contains(t, err.Error(), "seq b")
+	require.Contains(t, err.Error(), "position 3")
+}
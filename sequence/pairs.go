@@ -0,0 +1,84 @@
+package sequence
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// PairMatrix is a symmetric matrix of results of all-vs-all allignment
+type PairMatrix struct {
+	Scores [][]float64
+	// Stats are nil when only scores were requested
+	Stats [][]PairStats
+}
+
+// AllVsAll alligns every pair of seqs including each sequence with itself.
+// pairAlg returns Alligner for pair i, j, so position dependent scoring can be used.
+// Pairs are scheduled over opts.Threads workers, each pair is alligned in one thread.
+// If withStats is false only scores are calculated using linear memory Score.
+func AllVsAll(pairAlg func(i, j int) Alligner, seqs []string, opts Options, withStats bool) (*PairMatrix, error) {
+	workers := opts.Threads
+	if workers <= 0 {
+		workers = 1
+	}
+	opts.Threads = 1
+
+	n := len(seqs)
+	res := &PairMatrix{Scores: make([][]float64, n)}
+	for i := range res.Scores {
+		res.Scores[i] = make([]float64, n)
+	}
+	if withStats {
+		res.Stats = make([][]PairStats, n)
+		for i := range res.Stats {
+			res.Stats[i] = make([]PairStats, n)
+		}
+	}
+
+	pairs := make(chan cell, 2*workers)
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for p := range pairs {
+				if errs[w] != nil {
+					continue
+				}
+				alg := pairAlg(p.i, p.j)
+				if !withStats {
+					score, err := Score(alg, seqs[p.i], seqs[p.j], opts)
+					if err != nil {
+						errs[w] = errors.WithMessagef(err, "pair %d, %d", p.i, p.j)
+						continue
+					}
+					res.Scores[p.i][p.j], res.Scores[p.j][p.i] = score, score
+					continue
+				}
+				al, err := AllignWithOptions(alg, seqs[p.i], seqs[p.j], opts)
+				if err != nil {
+					errs[w] = errors.WithMessagef(err, "pair %d, %d", p.i, p.j)
+					continue
+				}
+				stats := AllignmentStats(alg, al.ResA, al.ResB)
+				res.Scores[p.i][p.j], res.Scores[p.j][p.i] = al.Score, al.Score
+				res.Stats[p.i][p.j], res.Stats[p.j][p.i] = stats, stats
+			}
+		}(w)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			pairs <- cell{i: i, j: j}
+		}
+	}
+	close(pairs)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,192 @@
+package sequence
+
+import "github.com/pkg/errors"
+
+// ProfileColumn is the symbol marking profile columns in allignment results
+const ProfileColumn = '#'
+
+// ErrBadProfile is returned for profile of no rows or rows of different length
+var ErrBadProfile = errors.New("bad profile")
+
+// ResidueFreq is fraction of sequences of profile which have residue in a column
+type ResidueFreq struct {
+	Residue byte
+	Freq    float64
+}
+
+// Column of profile, frequencies of residues and gap sum to 1.
+// Opens is the part of Gap of rows which gap starts at the column.
+// Column scores are sum-of-pairs scores divided by amount of pairs: a pair of residues is scored
+// by substitution score, a residue against a gap of the column costs gap open of alg if the gap
+// starts at the column and gap extend otherwise, pairs of gaps score 0.
+type Column struct {
+	Residues []ResidueFreq
+	Gap      float64
+	Opens    float64
+}
+
+// gapCost is expected cost of a residue against a random row of the column, 0 for rows with residues
+func (c Column) gapCost(alg Alligner) float64 {
+	ext := alg.GapOpen()
+	if alg.IsExtended() {
+		ext = alg.GapExtend()
+	}
+	return c.Opens*alg.GapOpen() + (c.Gap-c.Opens)*ext
+}
+
+// Score is expected score of residue r at position j of the other sequence against the column
+// at position i under alg, PositionalAlligner scores pairs by CompareAt(i, residue, j, r)
+func (c Column) Score(alg Alligner, i, j int, r byte) float64 {
+	return c.score(alg, newCompareFunc(alg), i, j, r)
+}
+
+func (c Column) score(alg Alligner, cmp compareFunc, i, j int, r byte) float64 {
+	s := c.gapCost(alg)
+	for _, x := range c.Residues {
+		s += x.Freq * cmp(i, x.Residue, j, r)
+	}
+	return s
+}
+
+// ScoreColumn is expected score of pair of rows of the column at position i and column o
+// at position j, positions are used as by Score
+func (c Column) ScoreColumn(alg Alligner, i int, o Column, j int) float64 {
+	return c.scoreColumn(alg, newCompareFunc(alg), i, o, j)
+}
+
+func (c Column) scoreColumn(alg Alligner, cmp compareFunc, i int, o Column, j int) float64 {
+	s := (1-c.Gap)*o.gapCost(alg) + (1-o.Gap)*c.gapCost(alg)
+	for _, x := range c.Residues {
+		for _, y := range o.Residues {
+			s += x.Freq * y.Freq * cmp(i, x.Residue, j, y.Residue)
+		}
+	}
+	return s
+}
+
+// Profile is column-wise summary of alligned sequences
+type Profile struct {
+	Columns []Column
+}
+
+// NewProfile counts frequencies of residues and gaps in columns of alligned rows
+func NewProfile(alg Alligner, rows []string) (*Profile, error) {
+	if len(rows) == 0 {
+		return nil, errors.Wrap(ErrBadProfile, "no rows")
+	}
+	l := len(rows[0])
+	for r, row := range rows {
+		if len(row) != l {
+			return nil, errors.Wrapf(ErrBadProfile, "row %d has length %d, expected %d", r+1, len(row), l)
+		}
+		for i := 0; i < len(row); i++ {
+			if row[i] != alg.Gap() && !alg.InAlphabet(row[i]) {
+				return nil, errors.WithMessagef(
+					errors.Wrapf(ErrBadSeq, "symbol %q at position %d is not in alphabet", row[i], i+1),
+					"row %d", r+1)
+			}
+		}
+	}
+
+	w := 1 / float64(len(rows))
+	p := &Profile{Columns: make([]Column, l)}
+	for i := range p.Columns {
+		c := &p.Columns[i]
+		for _, row := range rows {
+			if row[i] == alg.Gap() {
+				c.Gap += w
+				if i == 0 || row[i-1] != alg.Gap() {
+					c.Opens += w
+				}
+				continue
+			}
+			found := false
+			for k := range c.Residues {
+				if c.Residues[k].Residue == row[i] {
+					c.Residues[k].Freq += w
+					found = true
+					break
+				}
+			}
+			if !found {
+				c.Residues = append(c.Residues, ResidueFreq{Residue: row[i], Freq: w})
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *Profile) Len() int {
+	return len(p.Columns)
+}
+
+func (p *Profile) placeholders() string {
+	res := make([]byte, p.Len())
+	for i := range res {
+		res[i] = ProfileColumn
+	}
+	return string(res)
+}
+
+// profileAlligner scores columns of profiles a and b, nil profile means the sequence is plain.
+// Pairs of residues are scored by CompareAt of alg with column indices if alg is PositionalAlligner.
+type profileAlligner struct {
+	Alligner
+	cmp compareFunc
+	a   *Profile
+	b   *Profile
+}
+
+func newProfileAlligner(alg Alligner, a, b *Profile) *profileAlligner {
+	return &profileAlligner{Alligner: alg, cmp: newCompareFunc(alg), a: a, b: b}
+}
+
+func (p *profileAlligner) CompareAt(i int, x byte, j int, y byte) float64 {
+	switch {
+	case p.a != nil && p.b != nil:
+		return p.a.Columns[i].scoreColumn(p.Alligner, p.cmp, i, p.b.Columns[j], j)
+	case p.a != nil:
+		return p.a.Columns[i].score(p.Alligner, p.cmp, i, j, y)
+	case p.b != nil:
+		s := p.b.Columns[j].gapCost(p.Alligner)
+		for _, r := range p.b.Columns[j].Residues {
+			s += r.Freq * p.cmp(i, x, j, r.Residue)
+		}
+		return s
+	}
+	return p.cmp(i, x, j, y)
+}
+
+func (p *profileAlligner) InAlphabet(b byte) bool {
+	return b == ProfileColumn || p.Alligner.InAlphabet(b)
+}
+
+// AllignProfileSeq alligns profile p with seq by the same DP as AllignWithOptions,
+// columns of profile are ProfileColumn symbols in ResA
+func AllignProfileSeq(alg Alligner, p *Profile, seq string, opts Options) (*Allignment, error) {
+	if err := checkSeq(alg, seq); err != nil {
+		return nil, errors.WithMessage(err, "seq b")
+	}
+	return AllignWithOptions(newProfileAlligner(alg, p, nil), p.placeholders(), seq, opts)
+}
+
+// AllignProfiles alligns profiles a and b by the same DP as AllignWithOptions,
+// columns of profiles are ProfileColumn symbols in ResA and ResB
+func AllignProfiles(alg Alligner, a, b *Profile, opts Options) (*Allignment, error) {
+	return AllignWithOptions(newProfileAlligner(alg, a, b), a.placeholders(), b.placeholders(), opts)
+}
+
+// InsertGaps inserts gaps into alligned row where alligned result of its profile has gaps
+func InsertGaps(alg Alligner, row, alligned string) string {
+	res := make([]byte, 0, len(alligned))
+	c := 0
+	for k := 0; k < len(alligned); k++ {
+		if alligned[k] == alg.Gap() {
+			res = append(res, alg.Gap())
+			continue
+		}
+		res = append(res, row[c])
+		c++
+	}
+	return string(res)
+}
@@ -0,0 +1,105 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProfile(t *testing.T) {
+	allg := NewAlligerDNA(-10, -1)
+	p, err := NewProfile(allg, []string{"AC-T", "AG-T", "AC-A", "-CGT"})
+	require.NoError(t, err)
+	require.Equal(t, 4, p.Len())
+	require.Equal(t, Column{Residues: []ResidueFreq{{'A', 0.75}}, Gap: 0.25, Opens: 0.25}, p.Columns[0])
+	require.Equal(t, Column{Residues: []ResidueFreq{{'C', 0.75}, {'G', 0.25}}}, p.Columns[1])
+	require.Equal(t, Column{Residues: []ResidueFreq{{'G', 0.25}}, Gap: 0.75, Opens: 0.75}, p.Columns[2])
+
+	require.Equal(t, 0.75*5-0.25*4, p.Columns[1].Score(allg, 1, 0, 'C'))
+	// column of 3 opened gaps and G: a quarter of G-G and 3 quarters of gap open
+	require.Equal(t, 0.25*5-0.75*10, p.Columns[2].Score(allg, 2, 0, 'G'))
+	require.Equal(t, -0.4375-0.75*10, p.Columns[1].ScoreColumn(allg, 1, p.Columns[2], 2))
+
+	// positional scores use indices of columns
+	masked := NewMaskedAlligner(allg, []bool{false, true}, []bool{false, false, true}, 0.5)
+	require.Equal(t, (0.75*5-0.25*4)/2, p.Columns[1].Score(masked, 1, 0, 'C'))
+	require.Equal(t, 0.75*5-0.25*4, p.Columns[1].Score(masked, 2, 0, 'C'))
+	require.Equal(t, -0.4375/2-0.75*10, p.Columns[1].ScoreColumn(masked, 0, p.Columns[2], 2))
+
+	// gap of the second row continues at column 3
+	p, err = NewProfile(allg, []string{"ACGGT", "AC--T"})
+	require.NoError(t, err)
+	require.Equal(t, 0.5, p.Columns[2].Opens)
+	require.Equal(t, float64(0), p.Columns[3].Opens)
+	require.Equal(t, 0.5*5-0.5*10, p.Columns[2].Score(allg, 2, 0, 'G'))
+	require.Equal(t, 0.5*5-0.5*1, p.Columns[3].Score(allg, 3, 0, 'G'))
+	// pairs of gaps score 0, residues against gaps of the other column cost their open or extend
+	require.Equal(t, 0.5*0.5*5-0.5*0.5*10-0.5*0.5*10, p.Columns[2].ScoreColumn(allg, 2, p.Columns[2], 2))
+
+	for _, rows := range [][]string{nil, {"AC", "A"}, {"AC", "AJ"}} {
+		_, err := NewProfile(allg, rows)
+		require.Error(t, err)
+	}
+	_, err = NewProfile(allg, []string{"AC", "A"})
+	require.Equal(t, ErrBadProfile, errors.Cause(err))
+}
+
+func TestAllignProfiles(t *testing.T) {
+	a, b := "MKTAYIAKQRQISFVKSHFSRQ", "MKTAWIAKQISFVKSHFSRQLEE"
+	for _, allg := range []Alligner{NewAlligerBLOSUM62(-4, -4), NewAlligerBLOSUM62(-11, -1)} {
+		pa, err := NewProfile(allg, []string{a})
+		require.NoError(t, err)
+		pb, err := NewProfile(allg, []string{b})
+		require.NoError(t, err)
+		for _, opts := range []Options{{}, {MemoryOpt: true}, {Mode: ModeLocal}} {
+			exp, err := AllignWithOptions(allg, a, b, opts)
+			require.NoError(t, err)
+
+			res, err := AllignProfileSeq(allg, pa, b, opts)
+			require.NoError(t, err)
+			require.Equal(t, exp.Score, res.Score)
+			require.Equal(t, exp.ResA, InsertGaps(allg, a[res.BegA:res.EndA], res.ResA))
+			require.Equal(t, exp.ResB, res.ResB)
+
+			res, err = AllignProfiles(allg, pa, pb, opts)
+			require.NoError(t, err)
+			require.Equal(t, exp.Score, res.Score)
+			require.Equal(t, exp.ResA, InsertGaps(allg, a[res.BegA:res.EndA], res.ResA))
+			require.Equal(t, exp.ResB, InsertGaps(allg, b[res.BegB:res.EndB], res.ResB))
+		}
+	}
+
+	// positional scores of one-row profiles are the scores of their sequences
+	allg := NewAlligerBLOSUM62(-11, -1)
+	maskA, maskB := make([]bool, len(a)), make([]bool, len(b))
+	for i := 3; i < 10; i++ {
+		maskA[i], maskB[i+2] = true, true
+	}
+	masked := NewMaskedAlligner(allg, maskA, maskB, 0.2)
+	pa, err := NewProfile(allg, []string{a})
+	require.NoError(t, err)
+	pb, err := NewProfile(allg, []string{b})
+	require.NoError(t, err)
+	exp, err := AllignWithOptions(masked, a, b, Options{})
+	require.NoError(t, err)
+	res, err := AllignProfileSeq(masked, pa, b, Options{})
+	require.NoError(t, err)
+	require.Equal(t, exp.Score, res.Score)
+	res, err = AllignProfiles(masked, pa, pb, Options{})
+	require.NoError(t, err)
+	require.Equal(t, exp.Score, res.Score)
+
+	allg = NewAlligerDNA(-10, -1)
+	p, err := NewProfile(allg, []string{"ACGT-A", "AC-TTA"})
+	require.NoError(t, err)
+	res, err = AllignProfileSeq(allg, p, "ACTTA", Options{})
+	require.NoError(t, err)
+	require.Equal(t, "######", res.ResA)
+	require.Equal(t, "AC-TTA", res.ResB)
+	// the second row of column 4 opens a gap against T
+	require.Equal(t, float64(5+5-10+5+(2.5-5)+5), res.Score)
+
+	_, err = AllignProfileSeq(allg, p, "AC#A", Options{})
+	require.Equal(t, ErrBadSeq, errors.Cause(err))
+}
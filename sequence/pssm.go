@@ -0,0 +1,168 @@
+package sequence
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadPSSM is returned when position-specific scoring matrix can not be parsed
+var ErrBadPSSM = errors.New("bad pssm")
+
+// PSSM is position-specific scoring matrix of a query sequence
+type PSSM struct {
+	Query string
+	// Scores of residues at every position of query, columns are in order of Residues
+	Scores   [][]float64
+	Residues []byte
+
+	byteToIdx map[byte]int
+}
+
+// NewPSSM returns PSSM of query with scores of residues at every position
+func NewPSSM(query string, residues []byte, scores [][]float64) (*PSSM, error) {
+	if len(scores) != len(query) {
+		return nil, errors.Wrapf(ErrBadPSSM, "%d positions for query of length %d", len(scores), len(query))
+	}
+	p := &PSSM{
+		Query:     query,
+		Scores:    scores,
+		Residues:  residues,
+		byteToIdx: make(map[byte]int, len(residues)),
+	}
+	for i, r := range residues {
+		if _, ok := p.byteToIdx[r]; ok {
+			return nil, errors.Wrapf(ErrBadPSSM, "duplicated residue %q", r)
+		}
+		p.byteToIdx[r] = i
+	}
+	for i, row := range scores {
+		if len(row) != len(residues) {
+			return nil, errors.Wrapf(ErrBadPSSM, "position %d: expected %d scores, got %d", i+1, len(residues), len(row))
+		}
+	}
+	return p, nil
+}
+
+// Score returns score of residue b at position i of query, false if PSSM has no such position or residue
+func (p *PSSM) Score(i int, b byte) (float64, bool) {
+	idx, ok := p.byteToIdx[b]
+	if !ok || i < 0 || i >= len(p.Scores) {
+		return 0, false
+	}
+	return p.Scores[i][idx], true
+}
+
+// ParsePSSM reads ASCII PSSM as written by PSI-BLAST (-out_ascii_pssm): header with residue symbols
+// is followed by a line for every query position with the position, query residue and scores.
+// Observed percentages, information content and statistics after the scores are ignored.
+func ParsePSSM(r io.Reader) (*PSSM, error) {
+	var residues []byte
+	var scores [][]float64
+	query := strings.Builder{}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			if scores != nil {
+				break
+			}
+			continue
+		}
+		if residues == nil {
+			if isPSSMHeader(fields) {
+				residues = pssmResidues(fields)
+			}
+			continue
+		}
+
+		pos, err := strconv.Atoi(fields[0])
+		if err != nil {
+			if scores != nil {
+				break
+			}
+			return nil, errors.Wrapf(ErrBadPSSM, "line %d: bad position %q", line, fields[0])
+		}
+		if pos != len(scores)+1 {
+			return nil, errors.Wrapf(ErrBadPSSM, "line %d: expected position %d, got %d", line, len(scores)+1, pos)
+		}
+		if len(fields) < len(residues)+2 || len(fields[1]) != 1 {
+			return nil, errors.Wrapf(ErrBadPSSM, "line %d: expected residue and %d scores", line, len(residues))
+		}
+		row := make([]float64, len(residues))
+		for i, f := range fields[2 : len(residues)+2] {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, errors.Wrapf(ErrBadPSSM, "line %d: bad score %q", line, f)
+			}
+			row[i] = v
+		}
+		query.WriteString(strings.ToUpper(fields[1]))
+		scores = append(scores, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if residues == nil {
+		return nil, errors.Wrap(ErrBadPSSM, "no header")
+	}
+	if scores == nil {
+		return nil, errors.Wrap(ErrBadPSSM, "no positions")
+	}
+	return NewPSSM(query.String(), residues, scores)
+}
+
+// isPSSMHeader reports if all fields are residue symbols
+func isPSSMHeader(fields []string) bool {
+	for _, f := range fields {
+		if len(f) != 1 || !isLetter(f[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// pssmResidues returns symbols of header up to the first repeated one,
+// PSI-BLAST repeats them for columns of observed percentages
+func pssmResidues(fields []string) []byte {
+	seen := make(map[byte]bool)
+	var res []byte
+	for _, f := range fields {
+		if seen[f[0]] {
+			break
+		}
+		seen[f[0]] = true
+		res = append(res, f[0])
+	}
+	return res
+}
+
+type pssmAlligner struct {
+	Alligner
+	cmp  compareFunc
+	pssm *PSSM
+}
+
+// NewPSSMAlligner returns Alligner which scores residues of the second sequence against positions
+// of pssm, the first sequence should be the query of pssm. Residues which pssm does not have
+// (e.g. B, Z, X) fall back to scores of alg.
+func NewPSSMAlligner(alg Alligner, pssm *PSSM) PositionalAlligner {
+	return &pssmAlligner{
+		Alligner: alg,
+		cmp:      newCompareFunc(alg),
+		pssm:     pssm,
+	}
+}
+
+func (p *pssmAlligner) CompareAt(i int, a byte, j int, b byte) float64 {
+	if v, ok := p.pssm.Score(i, b); ok {
+		return v
+	}
+	return p.cmp(i, a, j, b)
+}
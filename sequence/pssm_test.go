@@ -0,0 +1,89 @@
+package sequence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const testPSSM = `
+Last position-specific scoring matrix computed, weighted observed percentages rounded down, information per position, and relative weight of gapless real matches to pseudocounts
+            A   R   N   D   C   Q   E   G   H   I   L   K   M   F   P   S   T   W   Y   V   A   R   N   D   C   Q   E   G   H   I   L   K   M   F   P   S   T   W   Y   V
+    1 M    -1  -2  -2  -3  -2  -1  -2  -3  -2   1   2  -2   6   0  -3  -2  -1  -2  -1   1    0   0   0   0   0   0   0   0   0   0   0   0 100   0   0   0   0   0   0   0  1.20 0.00
+    2 K    -1   2   0  -1  -3   1   1  -2  -1  -3  -2   5  -1  -3  -1   0  -1  -3  -2  -2    0   0   0   0   0   0   0   0   0   0   0 100   0   0   0   0   0   0   0   0  0.80 0.00
+    3 W    -3  -3  -4  -4  -2  -2  -3  -2  -2  -3  -2  -3  -1   1  -4  -3  -2  11   2  -3    0   0   0   0   0   0   0   0   0   0   0   0   0   0   0   0   0 100   0   0  2.90 0.00
+
+                      K         Lambda
+Standard Ungapped    0.1340     0.3176
+PSI Ungapped         0.1400     0.3200
+`
+
+func TestParsePSSM(t *testing.T) {
+	pssm, err := ParsePSSM(strings.NewReader(testPSSM))
+	require.NoError(t, err)
+	require.Equal(t, "MKW", pssm.Query)
+	require.Equal(t, []byte("ARNDCQEGHILKMFPSTWYV"), pssm.Residues)
+	require.Len(t, pssm.Scores, 3)
+	v, ok := pssm.Score(2, 'W')
+	require.True(t, ok)
+	require.Equal(t, float64(11), v)
+	_, ok = pssm.Score(2, 'X')
+	require.False(t, ok)
+	_, ok = pssm.Score(3, 'A')
+	require.False(t, ok)
+
+	tcs := []string{
+		"",
+		"A R\n",
+		"A R\n1 M 1\n",
+		"A R\n1 M 1 2\n3 K 1 2\n",
+		"A R\n1 M 1 x\n",
+		"A R\nM 1 2\n",
+	}
+	for i, tc := range tcs {
+		_, err := ParsePSSM(strings.NewReader(tc))
+		require.Error(t, err, "test %d", i)
+		require.Equal(t, ErrBadPSSM, errors.Cause(err), "test %d", i)
+	}
+}
+
+func TestPSSMAlligner(t *testing.T) {
+	query, b := "MKTAYIAKQRQISFVKSHFSRQ", "MKTAWIAKQISFVKSHFSRQLEE"
+	residues := []byte("ARNDCQEGHILKMFPSTWYV")
+	for _, allg := range []Alligner{NewAlligerBLOSUM62(-4, -4), NewAlligerBLOSUM62(-11, -1)} {
+		scores := make([][]float64, len(query))
+		for i := range scores {
+			scores[i] = make([]float64, len(residues))
+			for k, r := range residues {
+				scores[i][k] = allg.Compare(query[i], r)
+			}
+		}
+		pssm, err := NewPSSM(query, residues, scores)
+		require.NoError(t, err)
+		for _, opts := range []Options{{}, {MemoryOpt: true, Threads: 2}, {Mode: ModeLocal}} {
+			exp, err := AllignWithOptions(allg, query, b, opts)
+			require.NoError(t, err)
+			res, err := AllignWithOptions(NewPSSMAlligner(allg, pssm), query, b, opts)
+			require.NoError(t, err)
+			require.Equal(t, exp, res)
+		}
+	}
+
+	allg := NewAlligerBLOSUM62(-11, -1)
+	pssm, err := ParsePSSM(strings.NewReader(testPSSM))
+	require.NoError(t, err)
+	pssmAllg := NewPSSMAlligner(allg, pssm)
+	require.Equal(t, float64(11), pssmAllg.CompareAt(2, 'W', 0, 'W'))
+	require.Equal(t, float64(-1), pssmAllg.CompareAt(0, 'M', 0, 'A'))
+	require.Equal(t, allg.Compare('W', 'X'), pssmAllg.CompareAt(2, 'W', 0, 'X'))
+	for _, opts := range []Options{{}, {MemoryOpt: true}} {
+		res, err := AllignWithOptions(pssmAllg, pssm.Query, "MKW", opts)
+		require.NoError(t, err)
+		require.Equal(t, float64(6+5+11), res.Score)
+		score, err := Score(pssmAllg, pssm.Query, "MKW", opts)
+		require.NoError(t, err)
+		require.Equal(t, float64(6+5+11), score)
+	}
+}
@@ -0,0 +1,48 @@
+package sequence
+
+import "math"
+
+type qualityAlligner struct {
+	Alligner
+	cmp   compareFunc
+	probA []float64
+	probB []float64
+}
+
+// phredProbs returns probabilities that bases are called correctly
+func phredProbs(qual []byte) []float64 {
+	if qual == nil {
+		return nil
+	}
+	probs := make([]float64, len(qual))
+	for i, q := range qual {
+		probs[i] = 1 - math.Pow(10, -float64(q)/10)
+	}
+	return probs
+}
+
+// NewQualityAlligner returns Alligner which lowers mismatch penalty at low-confidence bases.
+// qualA and qualB are Phred qualities of the first and the second sequence, nil means all bases are certain.
+// Negative score of a pair is multiplied by probability that both bases are called correctly.
+func NewQualityAlligner(alg Alligner, qualA, qualB []byte) PositionalAlligner {
+	return &qualityAlligner{
+		Alligner: alg,
+		cmp:      newCompareFunc(alg),
+		probA:    phredProbs(qualA),
+		probB:    phredProbs(qualB),
+	}
+}
+
+func (q *qualityAlligner) CompareAt(i int, a byte, j int, b byte) float64 {
+	v := q.cmp(i, a, j, b)
+	if v >= 0 {
+		return v
+	}
+	if i < len(q.probA) {
+		v *= q.probA[i]
+	}
+	if j < len(q.probB) {
+		v *= q.probB[j]
+	}
+	return v
+}
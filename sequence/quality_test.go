@@ -0,0 +1,26 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQualityAlligner(t *testing.T) {
+	allg := NewQualityAlligner(NewAlligerDNA(-10, -10), []byte{40, 0, 10, 20}, nil)
+	require.Equal(t, float64(5), allg.CompareAt(1, 'A', 0, 'A'))
+	require.Equal(t, float64(0), allg.CompareAt(1, 'A', 0, 'T'))
+	require.InDelta(t, -4*0.9, allg.CompareAt(2, 'A', 0, 'T'), 1e-9)
+	require.InDelta(t, -4*0.99, allg.CompareAt(3, 'A', 0, 'T'), 1e-9)
+	require.InDelta(t, -4*0.99, allg.CompareAt(3, 'A', 5, 'T'), 1e-9)
+
+	res, err := AllignWithOptions(allg, "ATGC", "AAGC", Options{})
+	require.NoError(t, err)
+	require.Equal(t, "ATGC", res.ResA)
+	require.Equal(t, float64(15), res.Score)
+
+	masked := NewMaskedAlligner(allg, nil, []bool{false, false, true, true}, 0)
+	res, err = AllignWithOptions(masked, "ATGC", "AAGC", Options{})
+	require.NoError(t, err)
+	require.Equal(t, float64(5), res.Score)
+}
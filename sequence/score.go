@@ -0,0 +1,240 @@
+package sequence
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+type scoreCell struct {
+	mat float64
+	ins float64
+	del float64
+}
+
+// scoreTable calculates allignment score keeping only rolling rows.
+// Columns are split into stripes calculated in parallel the same way as in calcTable,
+// neighbour stripes exchange only values of the border column.
+type scoreTable struct {
+	alg    Alligner
+	gaps   gapModel
+	cmp    compareFunc
+	local  bool
+	band   *band
+	a      string
+	b      string
+	border [][]scoreCell
+	best   []float64
+	last   scoreCell
+}
+
+func (st *scoreTable) left(i int) scoreCell {
+	inf := math.Inf(-1)
+	if st.local {
+		if st.alg.IsExtended() {
+			return scoreCell{mat: inf, ins: inf, del: inf}
+		}
+		return scoreCell{}
+	}
+	if !st.alg.IsExtended() {
+		return scoreCell{mat: float64(i) * st.gaps.delOpen(0)}
+	}
+	if i == 0 {
+		return scoreCell{mat: 0, ins: inf, del: inf}
+	}
+	open, ext := st.gaps.del(0)
+	return scoreCell{mat: inf, ins: inf, del: open + float64(i-1)*ext}
+}
+
+func (st *scoreTable) top(j int) scoreCell {
+	inf := math.Inf(-1)
+	if st.local {
+		if st.alg.IsExtended() {
+			return scoreCell{mat: inf, ins: inf, del: inf}
+		}
+		return scoreCell{}
+	}
+	if !st.alg.IsExtended() {
+		return scoreCell{mat: float64(j) * st.gaps.insOpen(0)}
+	}
+	open, ext := st.gaps.ins(0)
+	return scoreCell{mat: inf, ins: open + float64(j-1)*ext, del: inf}
+}
+
+// edge returns border cell c of the table or unreachable cell if it is outside of band
+func (st *scoreTable) edge(i, j int, c scoreCell) scoreCell {
+	if st.band.out(i, j) {
+		inf := math.Inf(-1)
+		return scoreCell{mat: inf, ins: inf, del: inf}
+	}
+	return c
+}
+
+func (st *scoreTable) calc(i, j int, diag, up, left scoreCell) scoreCell {
+	cmp := st.cmp(i-1, st.a[i-1], j-1, st.b[j-1])
+	if !st.alg.IsExtended() {
+		v := maxFloat3(
+			up.mat+st.gaps.delOpen(j),
+			left.mat+st.gaps.insOpen(i),
+			diag.mat+cmp,
+		)
+		if st.local && v < 0 {
+			v = 0
+		}
+		return scoreCell{mat: v}
+	}
+	insOpen, insExt := st.gaps.ins(i)
+	delOpen, delExt := st.gaps.del(j)
+	prev := maxFloat3(diag.mat, diag.ins, diag.del)
+	if st.local && prev < 0 {
+		prev = 0
+	}
+	return scoreCell{
+		mat: prev + cmp,
+		ins: maxFloat3(left.mat+insOpen, left.ins+insExt, left.del+insOpen),
+		del: maxFloat3(up.mat+delOpen, up.ins+delOpen, up.del+delExt),
+	}
+}
+
+func (st *scoreTable) calcStripe(k, beg, end int, lBound, rBound *int32) {
+	prev := make([]scoreCell, end-beg+1)
+	cur := make([]scoreCell, end-beg+1)
+	best := float64(0)
+	prev[0] = st.left(0)
+	for j := beg; j < end; j++ {
+		prev[j-beg+1] = st.edge(0, j, st.top(j))
+	}
+	st.border[k][0] = prev[len(prev)-1]
+
+	for i := 1; i <= len(st.a); i++ {
+		for int32(i) > atomic.LoadInt32(lBound) {
+			runtime.Gosched()
+		}
+		if k == 0 {
+			cur[0] = st.edge(i, 0, st.left(i))
+		} else {
+			prev[0] = st.border[k-1][i-1]
+			cur[0] = st.border[k-1][i]
+		}
+		// only band is calculated, cells next to it are read by the next row and are set to -Inf
+		from, to := st.band.cols(i, len(st.b))
+		from, to = maxInt(beg, from), minInt(end-1, to)
+		inf := scoreCell{mat: math.Inf(-1), ins: math.Inf(-1), del: math.Inf(-1)}
+		if beg <= from-1 && from-1 < end {
+			cur[from-beg] = inf
+		}
+		if beg <= to+1 && to+1 < end {
+			cur[to-beg+2] = inf
+		}
+		for j := from; j <= to; j++ {
+			x := j - beg + 1
+			cur[x] = st.calc(i, j, prev[x-1], prev[x], cur[x-1])
+			if cur[x].mat > best {
+				best = cur[x].mat
+			}
+		}
+		if st.band.out(i, end-1) {
+			st.border[k][i] = inf
+		} else {
+			st.border[k][i] = cur[len(cur)-1]
+		}
+		atomic.AddInt32(rBound, 1)
+		prev, cur = cur, prev
+	}
+	st.best[k] = best
+	if end == len(st.b)+1 {
+		st.last = prev[len(prev)-1]
+	}
+}
+
+func (st *scoreTable) calcTable(amThreads int) {
+	if amThreads > len(st.b) {
+		amThreads = len(st.b)
+	}
+	rowsPoints := make([]int, amThreads+1)
+	rowsPoints[0] = 1
+	for i := 1; i < amThreads+1; i++ {
+		rowsPoints[i] = len(st.b) / amThreads
+		if len(st.b)%amThreads > i-1 {
+			rowsPoints[i]++
+		}
+		rowsPoints[i] += rowsPoints[i-1]
+	}
+	st.border = make([][]scoreCell, amThreads)
+	st.best = make([]float64, amThreads)
+	bounds := make([]int32, amThreads+1)
+	bounds[0] = int32(len(st.a))
+	wg := sync.WaitGroup{}
+	wg.Add(amThreads)
+	for i := 0; i < amThreads; i++ {
+		st.border[i] = make([]scoreCell, len(st.a)+1)
+		go func(i int) {
+			st.calcStripe(i, rowsPoints[i], rowsPoints[i+1], &bounds[i], &bounds[i+1])
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (st *scoreTable) score() float64 {
+	if st.local {
+		best := float64(0)
+		for _, v := range st.best {
+			best = math.Max(best, v)
+		}
+		return best
+	}
+	if len(st.b) == 0 {
+		st.last = st.left(len(st.a))
+	}
+	if !st.alg.IsExtended() {
+		return st.last.mat
+	}
+	return maxFloat3(st.last.mat, st.last.ins, st.last.del)
+}
+
+// Score returns score of the best allignment of a and b in mode set by opts.
+// It is the same score as AllignWithOptions returns, but only linear memory is used
+// and no allignment is built, so it suits ranking of large amount of pairs.
+// opts.Band limits calculation to a band the same way as in AllignWithOptions.
+func Score(alg Alligner, a, b string, opts Options) (float64, error) {
+	if err := checkSeqs(alg, a, b); err != nil {
+		return 0, err
+	}
+	if err := checkGaps(opts, a, b); err != nil {
+		return 0, err
+	}
+	if err := checkBand(opts); err != nil {
+		return 0, err
+	}
+	if opts.Threads <= 0 {
+		opts.Threads = 1
+	}
+	switch opts.Mode {
+	case ModeGlobal, ModeLocal, ModeSemiGlobal:
+	default:
+		return 0, errors.Errorf("unknown mode %d", opts.Mode)
+	}
+	if opts.Band > 0 {
+		_, score := bandedScore(alg, a, b, opts)
+		return score, nil
+	}
+	st := newScoreTable(alg, a, b, opts, nil)
+	st.calcTable(opts.Threads)
+	return st.score(), nil
+}
+
+func newScoreTable(alg Alligner, a, b string, opts Options, bd *band) *scoreTable {
+	return &scoreTable{
+		alg:   alg,
+		gaps:  newGapModel(alg, a, b, opts),
+		cmp:   newCompareFunc(alg),
+		local: opts.Mode == ModeLocal,
+		band:  bd,
+		a:     a,
+		b:     b,
+	}
+}
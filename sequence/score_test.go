@@ -0,0 +1,57 @@
+package sequence
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScore(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	tcs := []struct {
+		allg     Alligner
+		alphabet string
+	}{
+		{testAlligner(), "ABCD"},
+		{NewTableAlliger(-6, -1, testAlligner().(*tableAlliger).table, testAlligner().(*tableAlliger).byteToIdx), "ABCD"},
+		{NewAlligerBLOSUM62(-10, -10), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerBLOSUM62(-10, -1), "ARNDCQEGHILKMFPSTWYV"},
+		{NewAlligerDNA(-10, -1), "ATGC"},
+	}
+	modes := []Mode{ModeGlobal, ModeLocal, ModeSemiGlobal}
+	for _, tc := range tcs {
+		for n := 0; n < 40; n++ {
+			a := randomSeq(r, tc.alphabet, r.Intn(30))
+			b := randomSeq(r, tc.alphabet, r.Intn(30))
+			opts := Options{
+				Mode:     modes[r.Intn(len(modes))],
+				FreeEnds: FreeEnds{StartA: r.Intn(2) == 0, EndA: r.Intn(2) == 0, StartB: r.Intn(2) == 0, EndB: r.Intn(2) == 0},
+			}
+			res, err := AllignWithOptions(tc.allg, a, b, opts)
+			require.NoError(t, err)
+			for _, threads := range []int{1, 3} {
+				opts.Threads = threads
+				score, err := Score(tc.allg, a, b, opts)
+				require.NoError(t, err)
+				require.Equal(t, res.Score, score, "%s\n%s\n%+v", a, b, opts)
+			}
+		}
+	}
+}
+
+func TestScoreBadSeq(t *testing.T) {
+	_, err := Score(testAlligner(), "AB", "AZ", Options{})
+	require.Error(t, err)
+}
+
+func BenchmarkScoreOneThread(b *testing.B) {
+	a := "SPETVIHSGWVIWRELFSHWPDQCKLLFGDWFAWIHWTYLVYYSAGPPCQGQSDIVVMMQKKLRTNFCQCYKYWYQ"
+	c := "SPSDQFFTVIHSCLYWVIWRDLMSHLFMNGAAIDIHWTWDSIAIGPPLVYPIEEVFAGPSTIVVMMQKMLRTNFCQCYKPWYQ"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Score(benchAllg, a, c, Options{Threads: 1})
+	}
+}
@@ -0,0 +1,184 @@
+package sequence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCaseSemi struct {
+	a     string
+	b     string
+	ends  FreeEnds
+	resA  string
+	resB  string
+	score float64
+}
+
+func checkScoreEnds(allg Alligner, a, b string, ends FreeEnds) float64 {
+	return checkScoreOpts(allg, a, b, Options{Mode: ModeSemiGlobal, FreeEnds: ends})
+}
+
+func checkScoreOpts(allg Alligner, a, b string, opts Options) float64 {
+	lenA, lenB := len(removeGaps(allg, a)), len(removeGaps(allg, b))
+	g := newGapModel(allg, removeGaps(allg, a), removeGaps(allg, b), opts)
+	score := float64(0)
+	i, j := 0, 0
+	prev := dirMat
+	for c := range a {
+		switch {
+		case a[c] == allg.Gap():
+			open, ext := g.ins(i)
+			if prev == dirIns && allg.IsExtended() {
+				score += ext
+			} else {
+				score += open
+			}
+			prev = dirIns
+			j++
+		case b[c] == allg.Gap():
+			open, ext := g.del(j)
+			if prev == dirDel && allg.IsExtended() {
+				score += ext
+			} else {
+				score += open
+			}
+			prev = dirDel
+			i++
+		default:
+			score += allg.Compare(a[c], b[c])
+			prev = dirMat
+			i++
+			j++
+		}
+	}
+	if i != lenA || j != lenB {
+		panic("bad allignment")
+	}
+	return score
+}
+
+func runSemiTestCases(t *testing.T, allg Alligner, tcs []testCaseSemi) {
+	for _, threads := range []int{1, 8} {
+		for i, tc := range tcs {
+			opts := Options{Mode: ModeSemiGlobal, FreeEnds: tc.ends, Threads: threads}
+			res, err := AllignWithOptions(allg, tc.a, tc.b, opts)
+			require.NoError(t, err)
+			require.Equal(t, res.Score, checkScoreEnds(allg, res.ResA, res.ResB, tc.ends), "incosistent result on\n%s\n%s", res.ResA, res.ResB)
+			require.Equal(t, tc.score, res.Score, "failed SCORE test %d", i)
+			if tc.resA != "" || tc.resB != "" {
+				require.Equal(t, tc.resA, res.ResA, "failed seq A test %d", i)
+				require.Equal(t, tc.resB, res.ResB, "failed seq B test %d", i)
+			}
+			opts.MemoryOpt = true
+			res, err = AllignWithOptions(allg, tc.a, tc.b, opts)
+			require.NoError(t, err)
+			require.Equal(t, res.Score, checkScoreEnds(allg, res.ResA, res.ResB, tc.ends), "incosistent result on\n%s\n%s", res.ResA, res.ResB)
+			require.Equal(t, tc.score, res.Score, "failed SCORE mem opt test %d", i)
+		}
+	}
+}
+
+func TestAllgSemiGlobal(t *testing.T) {
+	allg := testAlligner()
+	tcs := []testCaseSemi{
+		{
+			a:     "",
+			b:     "ABC",
+			ends:  FreeEnds{StartB: true},
+			resA:  "---",
+			resB:  "ABC",
+			score: 0,
+		},
+		{
+			a:     "AAB",
+			b:     "CCAABCC",
+			ends:  FreeEnds{StartB: true, EndB: true},
+			resA:  "--AAB--",
+			resB:  "CCAABCC",
+			score: 15,
+		},
+		{
+			a:     "CCAABCC",
+			b:     "AAB",
+			ends:  FreeEnds{StartA: true, EndA: true},
+			resA:  "CCAABCC",
+			resB:  "--AAB--",
+			score: 15,
+		},
+		{
+			a:     "CCCAAB",
+			b:     "AABDDD",
+			ends:  FreeEnds{StartA: true, EndB: true},
+			resA:  "CCCAAB---",
+			resB:  "---AABDDD",
+			score: 15,
+		},
+		{
+			a:     "AABDDD",
+			b:     "CCCAAB",
+			ends:  FreeEnds{StartB: true, EndA: true},
+			resA:  "---AABDDD",
+			resB:  "CCCAAB---",
+			score: 15,
+		},
+		{
+			a:     "AAA",
+			b:     "BBB",
+			ends:  FreeEnds{StartA: true, EndA: true, StartB: true, EndB: true},
+			score: 0,
+		},
+		{
+			a:     "AACD",
+			b:     "BCD",
+			resA:  "AACD",
+			resB:  "-BCD",
+			score: 1,
+		},
+		{
+			a:     "ABDABCDD",
+			b:     "DDABCDDCC",
+			ends:  FreeEnds{StartB: true, EndB: true},
+			resA:  "ABDABCDD--",
+			resB:  "-DDABCDDCC",
+			score: 21,
+		},
+	}
+	runSemiTestCases(t, allg, tcs)
+}
+
+func TestAllgSemiGlobalExt(t *testing.T) {
+	allg := NewTableAlliger(-6, -1, testAlligner().(*tableAlliger).table, testAlligner().(*tableAlliger).byteToIdx)
+	tcs := []testCaseSemi{
+		{
+			a:     "AAB",
+			b:     "CCCCAABCCCC",
+			ends:  FreeEnds{StartB: true, EndB: true},
+			resA:  "----AAB----",
+			resB:  "CCCCAABCCCC",
+			score: 15,
+		},
+		{
+			a:     "AAB",
+			b:     "CCCCAABCCCC",
+			score: -3,
+		},
+		{
+			a:     "CCCAAB",
+			b:     "AABDDD",
+			ends:  FreeEnds{StartA: true, EndB: true},
+			resA:  "CCCAAB---",
+			resB:  "---AABDDD",
+			score: 15,
+		},
+		{
+			a:     "AABBBBBBCC",
+			b:     "DAACC",
+			ends:  FreeEnds{StartB: true},
+			resA:  "-AABBBBBBCC",
+			resB:  "DAA------CC",
+			score: 9,
+		},
+	}
+	runSemiTestCases(t, allg, tcs)
+}
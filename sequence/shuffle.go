@@ -0,0 +1,203 @@
+package sequence
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ShuffleKind selects what is kept by shuffling of a sequence
+type ShuffleKind int
+
+const (
+	// ShufflePlain is a random permutation of residues, it keeps composition of sequence
+	ShufflePlain ShuffleKind = iota
+	// ShuffleDinucleotide keeps counts of every pair of adjacent residues (Altschul & Erickson, 1985),
+	// so composition and the first and the last residue are kept too
+	ShuffleDinucleotide
+)
+
+func (k ShuffleKind) String() string {
+	switch k {
+	case ShufflePlain:
+		return "plain"
+	case ShuffleDinucleotide:
+		return "dinucleotide"
+	}
+	return "unknown"
+}
+
+// Shuffle returns random shuffle of s of kind
+func Shuffle(r *rand.Rand, s string, kind ShuffleKind) string {
+	if kind == ShuffleDinucleotide {
+		return shuffleDoublets(r, s)
+	}
+	res := []byte(s)
+	r.Shuffle(len(res), func(i, j int) {
+		res[i], res[j] = res[j], res[i]
+	})
+	return string(res)
+}
+
+// shuffleDoublets makes random Euler path of graph with edge s[i] -> s[i+1] for every i (Kandel et al., 1996).
+// Last edges out of residues are a random tree to the last residue made by loop-erased walks (Wilson, 1996),
+// the other edges are in random order.
+func shuffleDoublets(r *rand.Rand, s string) string {
+	if len(s) < 3 {
+		return s
+	}
+	var edges [256][]byte
+	for i := 0; i+1 < len(s); i++ {
+		edges[s[i]] = append(edges[s[i]], s[i+1])
+	}
+	root := s[len(s)-1]
+
+	var inTree [256]bool
+	var next [256]int
+	inTree[root] = true
+	for v := 0; v < 256; v++ {
+		if len(edges[v]) == 0 {
+			continue
+		}
+		for u := v; !inTree[u]; u = int(edges[u][next[u]]) {
+			next[u] = r.Intn(len(edges[u]))
+		}
+		for u := v; !inTree[u]; u = int(edges[u][next[u]]) {
+			inTree[u] = true
+		}
+		// chosen edge is moved to the end, the others are shuffled
+		out := edges[v]
+		last := len(out) - 1
+		if v != int(root) {
+			out[next[v]], out[last] = out[last], out[next[v]]
+			last--
+		}
+		r.Shuffle(last+1, func(i, j int) {
+			out[i], out[j] = out[j], out[i]
+		})
+	}
+
+	res := make([]byte, len(s))
+	res[0] = s[0]
+	var used [256]int
+	for i := 1; i < len(res); i++ {
+		v := res[i-1]
+		res[i] = edges[v][used[v]]
+		used[v]++
+	}
+	return string(res)
+}
+
+// ShuffleOptions configures ShuffleSignificance
+type ShuffleOptions struct {
+	// N is amount of shuffles
+	N    int
+	Kind ShuffleKind
+	// Seed of random generator, every shuffle has its own generator,
+	// so the same seed gives the same result with any amount of threads
+	Seed int64
+	// Threads is amount of workers alligning shuffles, every shuffle is alligned in one thread
+	Threads int
+}
+
+// DefaultShuffleOptions are used by ShuffleSignificance for zero amount of shuffles
+var DefaultShuffleOptions = ShuffleOptions{N: 500, Kind: ShufflePlain, Seed: 1, Threads: 1}
+
+// Significance is score of allignment compared with scores of allignments of shuffled sequences
+type Significance struct {
+	Score float64
+	// Scores are the best scores of shuffles in order of shuffles
+	Scores []float64
+	Mean   float64
+	SD     float64
+	// Z is (Score - Mean) / SD
+	Z float64
+	// Gumbel is extreme value distribution fitted to Scores
+	Gumbel Gumbel
+	// PValue is probability of score at least Score by Gumbel
+	PValue float64
+	// EmpiricalPValue is (k+1)/(N+1), where k is amount of shuffles with score at least Score
+	EmpiricalPValue float64
+}
+
+// ShuffleSignificance alligns a with b and N shuffles of b, opts are used for every allignment.
+// Error is returned if all shuffles have the same score, e.g. for homopolymer b.
+// Gumbel distribution describes the best local allignment scores, it is an approximation in other modes.
+func ShuffleSignificance(alg Alligner, a, b string, opts Options, sopts ShuffleOptions) (*Significance, error) {
+	if sopts.N == 0 {
+		sopts = DefaultShuffleOptions
+	}
+	if sopts.N < 2 {
+		return nil, errors.Errorf("%d shuffles are not enough to fit distribution", sopts.N)
+	}
+	if sopts.Kind != ShufflePlain && sopts.Kind != ShuffleDinucleotide {
+		return nil, errors.Errorf("unknown shuffle kind %d", sopts.Kind)
+	}
+	workers := sopts.Threads
+	if workers <= 0 {
+		workers = 1
+	}
+	opts.Threads = 1
+
+	score, err := Score(alg, a, b, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := &Significance{Score: score, Scores: make([]float64, sopts.N)}
+
+	shuffles := make(chan int, 2*workers)
+	errs := make([]error, workers)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := range shuffles {
+				if errs[w] != nil {
+					continue
+				}
+				r := rand.New(rand.NewSource(sopts.Seed + int64(i)))
+				res.Scores[i], errs[w] = Score(alg, a, Shuffle(r, b, sopts.Kind), opts)
+				if errs[w] != nil {
+					errs[w] = errors.WithMessagef(errs[w], "shuffle %d", i)
+				}
+			}
+		}(w)
+	}
+	for i := 0; i < sopts.N; i++ {
+		shuffles <- i
+	}
+	close(shuffles)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if allEqual(res.Scores) {
+		return nil, errors.Errorf("shuffled scores are degenerate: all %d scores are %g, sequence is too short or low-complexity", sopts.N, res.Scores[0])
+	}
+	above := 0
+	for _, s := range res.Scores {
+		res.Mean += s
+		if s >= score {
+			above++
+		}
+	}
+	res.Mean /= float64(sopts.N)
+	for _, s := range res.Scores {
+		res.SD += (s - res.Mean) * (s - res.Mean)
+	}
+	res.SD = math.Sqrt(res.SD / float64(sopts.N-1))
+	res.EmpiricalPValue = float64(above+1) / float64(sopts.N+1)
+	res.Gumbel, err = FitGumbel(res.Scores)
+	if err != nil {
+		return nil, errors.Wrap(err, "shuffled scores")
+	}
+	res.Z = (score - res.Mean) / res.SD
+	res.PValue = res.Gumbel.PValue(score)
+	return res, nil
+}
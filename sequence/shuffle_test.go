@@ -0,0 +1,115 @@
+package sequence
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sortedResidues(s string) string {
+	res := []byte(s)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return string(res)
+}
+
+func doublets(s string) map[string]int {
+	res := map[string]int{}
+	for i := 0; i+1 < len(s); i++ {
+		res[s[i:i+2]]++
+	}
+	return res
+}
+
+func TestShuffle(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, alphabet := range []string{"ACGT", "ARNDCQEGHILKMFPSTWYV", "AC"} {
+		for n := 0; n < 50; n++ {
+			s := randomSeq(r, alphabet, r.Intn(80))
+			plain := Shuffle(r, s, ShufflePlain)
+			require.Equal(t, sortedResidues(s), sortedResidues(plain))
+
+			res := Shuffle(r, s, ShuffleDinucleotide)
+			require.Equal(t, len(s), len(res))
+			require.Equal(t, doublets(s), doublets(res), s)
+			if len(s) > 0 {
+				require.Equal(t, s[0], res[0])
+				require.Equal(t, s[len(s)-1], res[len(res)-1])
+			}
+		}
+	}
+
+	// every sequence with the same doublets is made
+	seen := map[string]bool{}
+	for n := 0; n < 500; n++ {
+		seen[Shuffle(r, "ACAGCGA", ShuffleDinucleotide)] = true
+	}
+	exp := map[string]bool{}
+	var permute func(prefix, rest string)
+	permute = func(prefix, rest string) {
+		if rest == "" {
+			if reflect.DeepEqual(doublets(prefix), doublets("ACAGCGA")) {
+				exp[prefix] = true
+			}
+			return
+		}
+		for i := range rest {
+			permute(prefix+rest[i:i+1], rest[:i]+rest[i+1:])
+		}
+	}
+	permute("", "ACAGCGA")
+	require.Len(t, exp, 6)
+	require.Equal(t, exp, seen)
+
+	a := Shuffle(rand.New(rand.NewSource(1)), "ACGTTGCAAGT", ShuffleDinucleotide)
+	b := Shuffle(rand.New(rand.NewSource(1)), "ACGTTGCAAGT", ShuffleDinucleotide)
+	require.Equal(t, a, b)
+}
+
+func TestShuffleSignificance(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	alphabet := "ARNDCQEGHILKMFPSTWYV"
+	allg := NewAlligerBLOSUM62(-12, -1)
+	opts := Options{Mode: ModeLocal, Threads: 1}
+	a := randomSeq(r, alphabet, 120)
+	related := mutate(r, alphabet, a, 0.3)
+
+	sopts := ShuffleOptions{N: 100, Kind: ShufflePlain, Seed: 7, Threads: 1}
+	res, err := ShuffleSignificance(allg, a, related, opts, sopts)
+	require.NoError(t, err)
+	score, err := Score(allg, a, related, opts)
+	require.NoError(t, err)
+	require.Equal(t, score, res.Score)
+	require.Len(t, res.Scores, 100)
+	require.Greater(t, res.Z, 5.0)
+	require.Less(t, res.PValue, 1e-4)
+	require.InDelta(t, 1.0/101, res.EmpiricalPValue, 1e-12)
+
+	// result does not depend on amount of threads
+	sopts.Threads = 3
+	same, err := ShuffleSignificance(allg, a, related, opts, sopts)
+	require.NoError(t, err)
+	require.Equal(t, res, same)
+
+	sopts.Kind = ShuffleDinucleotide
+	random, err := ShuffleSignificance(allg, a, randomSeq(r, alphabet, 120), opts, sopts)
+	require.NoError(t, err)
+	require.Less(t, random.Z, 4.0)
+	require.Greater(t, random.PValue, 1e-3)
+
+	// shuffles of homopolymer are the same sequence
+	for _, kind := range []ShuffleKind{ShufflePlain, ShuffleDinucleotide} {
+		_, err = ShuffleSignificance(allg, "KKKKWKKK", "KKKKKKKKKK", opts, ShuffleOptions{N: 20, Kind: kind, Seed: 1, Threads: 2})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "degenerate")
+	}
+
+	_, err = ShuffleSignificance(allg, a, related, opts, ShuffleOptions{N: 1})
+	require.Error(t, err)
+	_, err = ShuffleSignificance(allg, a, "AJ", opts, sopts)
+	require.Error(t, err)
+}
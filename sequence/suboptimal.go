@@ -0,0 +1,72 @@
+package sequence
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// forbidAlligner forbids pairs of residues alligned by earlier allignments,
+// forbidden[i] are positions of b paired with a[i]
+type forbidAlligner struct {
+	Alligner
+	cmp       compareFunc
+	forbidden [][]int
+}
+
+func (f *forbidAlligner) CompareAt(i int, a byte, j int, b byte) float64 {
+	for _, fj := range f.forbidden[i] {
+		if fj == j {
+			return math.Inf(-1)
+		}
+	}
+	return f.cmp(i, a, j, b)
+}
+
+func (f *forbidAlligner) forbid(res *Allignment) {
+	i, j := res.BegA, res.BegB
+	for c := 0; c < len(res.ResA); c++ {
+		gapA, gapB := res.ResA[c] == f.Gap(), res.ResB[c] == f.Gap()
+		if !gapA && !gapB {
+			f.forbidden[i] = append(f.forbidden[i], j)
+		}
+		if !gapA {
+			i++
+		}
+		if !gapB {
+			j++
+		}
+	}
+}
+
+// AllignLocalBest returns up to k best local allignments of a and b in decreasing score order (Waterman-Eggert).
+// Every next allignment is the best one which does not allign any pair of residues alligned by the previous ones,
+// allignments with non-positive score are not reported. opts.Mode is ignored, table is recalculated for every
+// allignment, so it takes k times longer than AllignWithOptions.
+func AllignLocalBest(alg Alligner, a, b string, k int, opts Options) ([]*Allignment, error) {
+	if err := checkSeqs(alg, a, b); err != nil {
+		return nil, err
+	}
+	if opts.MemoryOpt {
+		return nil, errors.New("local mode is not supported with memory optimization")
+	}
+	opts.Mode = ModeLocal
+	f := &forbidAlligner{
+		Alligner:  alg,
+		cmp:       newCompareFunc(alg),
+		forbidden: make([][]int, len(a)),
+	}
+	var res []*Allignment
+	for len(res) < k {
+		r, err := AllignWithOptions(f, a, b, opts)
+		if err != nil {
+			return nil, err
+		}
+		if r.Score <= 0 {
+			break
+		}
+		res = append(res, r)
+		f.forbid(r)
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package sequence
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllignLocalBest(t *testing.T) {
+	tcs := []struct {
+		allg Alligner
+		exp  []Allignment
+	}{
+		{
+			allg: NewAlligerDNA(-10, -10),
+			exp: []Allignment{
+				{ResA: "ACGTACGT", ResB: "ACGTACGT", Score: 40, BegA: 3, EndA: 11, BegB: 2, EndB: 10},
+				{ResA: "TACGTCC", ResB: "TACGTAC", Score: 26, BegA: 6, EndA: 13, BegB: 1, EndB: 8},
+				{ResA: "ACGTAGG", ResB: "ACGTACG", Score: 26, BegA: 15, EndA: 22, BegB: 2, EndB: 9},
+			},
+		},
+		{
+			allg: NewAlligerDNA(-10, -1),
+			exp: []Allignment{
+				{ResA: "ACGTACGT", ResB: "ACGTACGT", Score: 40, BegA: 3, EndA: 11, BegB: 2, EndB: 10},
+				{ResA: "TACGTCCCCACGT", ResB: "TACGT----ACGT", Score: 32, BegA: 6, EndA: 19, BegB: 1, EndB: 10},
+				{ResA: "ACGTAGG", ResB: "ACGTACG", Score: 26, BegA: 15, EndA: 22, BegB: 2, EndB: 9},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		res, err := AllignLocalBest(tc.allg, "GGGACGTACGTCCCCACGTAGGG", "TTACGTACGTTT", 3, Options{})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		for k := range res {
+			require.Equal(t, tc.exp[k], *res[k])
+		}
+
+		res, err = AllignLocalBest(tc.allg, "AAAA", "TTTT", 3, Options{})
+		require.NoError(t, err)
+		require.Empty(t, res)
+	}
+
+	r := rand.New(rand.NewSource(7))
+	for _, allg := range []Alligner{NewAlligerBLOSUM62(-6, -6), NewAlligerBLOSUM62(-11, -1)} {
+		for n := 0; n < 20; n++ {
+			a := randomSeq(r, "ARNDCQEGHILKMFPSTWYV", 20+r.Intn(60))
+			b := randomSeq(r, "ARNDCQEGHILKMFPSTWYV", 20+r.Intn(60))
+			res, err := AllignLocalBest(allg, a, b, 5, Options{})
+			require.NoError(t, err)
+			best, err := AllignWithOptions(allg, a, b, Options{Mode: ModeLocal})
+			require.NoError(t, err)
+			require.Equal(t, best, res[0])
+
+			used := make(map[[2]int]bool)
+			for k, al := range res {
+				require.Equal(t, al.Score, checkScore(allg, al.ResA, al.ResB))
+				if k > 0 {
+					require.True(t, al.Score <= res[k-1].Score)
+				}
+				i, j := al.BegA, al.BegB
+				for c := range al.ResA {
+					gapA, gapB := al.ResA[c] == allg.Gap(), al.ResB[c] == allg.Gap()
+					if !gapA && !gapB {
+						require.False(t, used[[2]int{i, j}], "pair %d %d is reused", i, j)
+						used[[2]int{i, j}] = true
+					}
+					if !gapA {
+						i++
+					}
+					if !gapB {
+						j++
+					}
+				}
+				require.Equal(t, []int{al.EndA, al.EndB}, []int{i, j})
+			}
+		}
+	}
+}
@@ -45,3 +45,17 @@ func maxFloat3DirAlt(
 
 	return f, a
 }
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
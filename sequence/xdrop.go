@@ -0,0 +1,308 @@
+package sequence
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// xdropSide is extension from a seed in one direction: k-th residue of extension is a[i+step*k] and b[j+step*k],
+// lenA and lenB residues are available
+type xdropSide struct {
+	i, j       int
+	step       int
+	lenA, lenB int
+}
+
+func seedSides(a, b string, i, j int) (xdropSide, xdropSide) {
+	left := xdropSide{i: i - 1, j: j - 1, step: -1, lenA: i, lenB: j}
+	right := xdropSide{i: i + 1, j: j + 1, step: 1, lenA: len(a) - i - 1, lenB: len(b) - j - 1}
+	return left, right
+}
+
+func (s xdropSide) idxA(k int) int {
+	return s.i + s.step*k
+}
+
+func (s xdropSide) idxB(k int) int {
+	return s.j + s.step*k
+}
+
+// xdropRow is the part of row of table which was not dropped, cells[0] is at column lo
+type xdropRow struct {
+	lo    int
+	cells []scoreCell
+}
+
+// xdropTable is table of allignments of prefixes of extension, cell (i, j) has i residues of a and j residues of b.
+// Cells with score more than x below the best one are dropped, rows are limited to the rest of cells.
+type xdropTable struct {
+	alg  Alligner
+	cmp  compareFunc
+	a    string
+	b    string
+	side xdropSide
+	open float64
+	ext  float64
+	x    float64
+	rows []xdropRow
+}
+
+func deadCell() scoreCell {
+	inf := math.Inf(-1)
+	return scoreCell{mat: inf, ins: inf, del: inf}
+}
+
+func (xt *xdropTable) get(i, j int) scoreCell {
+	if i < 0 || i >= len(xt.rows) {
+		return deadCell()
+	}
+	r := xt.rows[i]
+	if j < r.lo || j >= r.lo+len(r.cells) {
+		return deadCell()
+	}
+	return r.cells[j-r.lo]
+}
+
+func (xt *xdropTable) compare(i, j int) float64 {
+	ia, jb := xt.side.idxA(i-1), xt.side.idxB(j-1)
+	return xt.cmp(ia, xt.a[ia], jb, xt.b[jb])
+}
+
+func (xt *xdropTable) calc(i, j int, left scoreCell) scoreCell {
+	c := deadCell()
+	if i > 0 && j > 0 {
+		d := xt.get(i-1, j-1)
+		c.mat = maxFloat3(d.mat, d.ins, d.del) + xt.compare(i, j)
+	}
+	if j > 0 {
+		c.ins = maxFloat3(left.mat+xt.open, left.ins+xt.ext, left.del+xt.open)
+	}
+	if i > 0 {
+		u := xt.get(i-1, j)
+		c.del = maxFloat3(u.mat+xt.open, u.ins+xt.open, u.del+xt.ext)
+	}
+	return c
+}
+
+// fill calculates rows until all cells of a row are dropped, returns the best cell and it's score
+func (xt *xdropTable) fill() (int, int, float64) {
+	best, bestI, bestJ := float64(0), 0, 0
+	// columns of the previous row
+	lo, hi := 0, -1
+	for i := 0; i <= xt.side.lenA; i++ {
+		row := xdropRow{lo: lo}
+		left := deadCell()
+		for j := lo; j <= xt.side.lenB; j++ {
+			c := scoreCell{mat: 0, ins: math.Inf(-1), del: math.Inf(-1)}
+			if i > 0 || j > 0 {
+				c = xt.calc(i, j, left)
+			}
+			v := maxFloat3(c.mat, c.ins, c.del)
+			if v < best-xt.x {
+				c = deadCell()
+			} else if v > best {
+				best, bestI, bestJ = v, i, j
+			}
+			row.cells = append(row.cells, c)
+			left = c
+			// right of the previous row cells are reached only from the left one
+			if j > hi && math.IsInf(c.ins, -1) && math.IsInf(c.mat, -1) {
+				break
+			}
+		}
+
+		first, last := -1, -1
+		for k, c := range row.cells {
+			if !math.IsInf(maxFloat3(c.mat, c.ins, c.del), -1) {
+				if first < 0 {
+					first = k
+				}
+				last = k
+			}
+		}
+		if first < 0 {
+			break
+		}
+		row.lo += first
+		row.cells = row.cells[first : last+1]
+		xt.rows = append(xt.rows, row)
+		lo, hi = row.lo, row.lo+len(row.cells)-1
+	}
+	return bestI, bestJ, best
+}
+
+// traceback returns allignment of extension up to cell (i, j), the first column is next to the seed
+func (xt *xdropTable) traceback(i, j int) (string, string) {
+	gap := xt.alg.Gap()
+	resA, resB := make([]byte, 0, i+j), make([]byte, 0, i+j)
+	c := xt.get(i, j)
+	_, state := maxFloat3DirAlt(c.mat, dirMat, c.ins, dirIns, c.del, dirDel)
+	for i > 0 || j > 0 {
+		c = xt.get(i, j)
+		switch state {
+		case dirMat:
+			resA = append(resA, xt.a[xt.side.idxA(i-1)])
+			resB = append(resB, xt.b[xt.side.idxB(j-1)])
+			d := xt.get(i-1, j-1)
+			_, state = maxFloat3DirAlt(d.mat, dirMat, d.ins, dirIns, d.del, dirDel)
+			i, j = i-1, j-1
+		case dirIns:
+			resA = append(resA, gap)
+			resB = append(resB, xt.b[xt.side.idxB(j-1)])
+			l := xt.get(i, j-1)
+			switch c.ins {
+			case l.mat + xt.open:
+				state = dirMat
+			case l.ins + xt.ext:
+				state = dirIns
+			default:
+				state = dirDel
+			}
+			j--
+		case dirDel:
+			resA = append(resA, xt.a[xt.side.idxA(i-1)])
+			resB = append(resB, gap)
+			u := xt.get(i-1, j)
+			switch c.del {
+			case u.mat + xt.open:
+				state = dirMat
+			case u.del + xt.ext:
+				state = dirDel
+			default:
+				state = dirIns
+			}
+			i--
+		}
+	}
+	return reverse(string(resA)), reverse(string(resB))
+}
+
+// extension is the best extension of one side: allignment and amount of residues of a and b in it
+type extension struct {
+	resA, resB string
+	lenA, lenB int
+	score      float64
+}
+
+func extendGapped(alg Alligner, a, b string, side xdropSide, x float64) extension {
+	xt := &xdropTable{
+		alg:  alg,
+		cmp:  newCompareFunc(alg),
+		a:    a,
+		b:    b,
+		side: side,
+		open: alg.GapOpen(),
+		ext:  alg.GapOpen(),
+		x:    x,
+	}
+	if alg.IsExtended() {
+		xt.ext = alg.GapExtend()
+	}
+	i, j, score := xt.fill()
+	resA, resB := xt.traceback(i, j)
+	return extension{resA: resA, resB: resB, lenA: i, lenB: j, score: score}
+}
+
+func extendUngapped(alg Alligner, a, b string, side xdropSide, x float64) extension {
+	cmp := newCompareFunc(alg)
+	n := minInt(side.lenA, side.lenB)
+	best, bestK, score := float64(0), 0, float64(0)
+	for k := 0; k < n; k++ {
+		ia, jb := side.idxA(k), side.idxB(k)
+		score += cmp(ia, a[ia], jb, b[jb])
+		if score > best {
+			best, bestK = score, k+1
+		}
+		if score < best-x {
+			break
+		}
+	}
+	resA, resB := make([]byte, bestK), make([]byte, bestK)
+	for k := 0; k < bestK; k++ {
+		resA[k], resB[k] = a[side.idxA(k)], b[side.idxB(k)]
+	}
+	return extension{resA: string(resA), resB: string(resB), lenA: bestK, lenB: bestK, score: best}
+}
+
+func checkSeed(alg Alligner, a, b string, i, j int, x float64) error {
+	if err := checkSeqs(alg, a, b); err != nil {
+		return err
+	}
+	if i < 0 || i >= len(a) || j < 0 || j >= len(b) {
+		return errors.Errorf("seed (%d, %d) is out of sequences of length %d and %d", i, j, len(a), len(b))
+	}
+	if x < 0 || math.IsNaN(x) {
+		return errors.Errorf("bad x-drop %v", x)
+	}
+	return nil
+}
+
+func seedAllignment(alg Alligner, a, b string, i, j int, left, right extension) *Allignment {
+	seed := newCompareFunc(alg)(i, a[i], j, b[j])
+	return &Allignment{
+		ResA:  reverse(left.resA) + a[i:i+1] + right.resA,
+		ResB:  reverse(left.resB) + b[j:j+1] + right.resB,
+		Score: left.score + seed + right.score,
+		BegA:  i - left.lenA,
+		EndA:  i + 1 + right.lenA,
+		BegB:  j - left.lenB,
+		EndB:  j + 1 + right.lenB,
+	}
+}
+
+// ExtendUngapped extends seed pair a[i], b[j] to the left and to the right without gaps (X-drop).
+// Extension in each direction stops when it's score falls more than x below the best one seen
+// and is cut back to the best one. Result has the seed pair and BegA, EndA, BegB, EndB of the segment.
+func ExtendUngapped(alg Alligner, a, b string, i, j int, x float64) (*Allignment, error) {
+	if err := checkSeed(alg, a, b, i, j, x); err != nil {
+		return nil, err
+	}
+	return (&Extender{alg: alg, a: a}).Ungapped(b, i, j, x), nil
+}
+
+// ExtendGapped is ExtendUngapped with gaps scored by alg. Cells of table with score more than x
+// below the best one are dropped, so only a part of table around the best path is calculated.
+func ExtendGapped(alg Alligner, a, b string, i, j int, x float64) (*Allignment, error) {
+	if err := checkSeed(alg, a, b, i, j, x); err != nil {
+		return nil, err
+	}
+	return (&Extender{alg: alg, a: a}).Gapped(b, i, j, x), nil
+}
+
+// Extender extends many seeds of query a, sequences are checked once instead of on every seed
+type Extender struct {
+	alg Alligner
+	a   string
+}
+
+// NewExtender checks query a and returns Extender of seeds of it
+func NewExtender(alg Alligner, a string) (*Extender, error) {
+	if err := checkSeq(alg, a); err != nil {
+		return nil, errors.WithMessage(err, "seq a")
+	}
+	return &Extender{alg: alg, a: a}, nil
+}
+
+// Check returns ErrBadSeq if b has symbols out of alphabet, b must be checked before extensions with it
+func (e *Extender) Check(b string) error {
+	return errors.WithMessage(checkSeq(e.alg, b), "seq b")
+}
+
+// Ungapped is ExtendUngapped of query with checked b,
+// seed must be inside of sequences and x must not be negative
+func (e *Extender) Ungapped(b string, i, j int, x float64) *Allignment {
+	leftSide, rightSide := seedSides(e.a, b, i, j)
+	left := extendUngapped(e.alg, e.a, b, leftSide, x)
+	right := extendUngapped(e.alg, e.a, b, rightSide, x)
+	return seedAllignment(e.alg, e.a, b, i, j, left, right)
+}
+
+// Gapped is ExtendGapped of query with checked b,
+// seed must be inside of sequences and x must not be negative
+func (e *Extender) Gapped(b string, i, j int, x float64) *Allignment {
+	leftSide, rightSide := seedSides(e.a, b, i, j)
+	left := extendGapped(e.alg, e.a, b, leftSide, x)
+	right := extendGapped(e.alg, e.a, b, rightSide, x)
+	return seedAllignment(e.alg, e.a, b, i, j, left, right)
+}
@@ -0,0 +1,114 @@
+package sequence
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendUngapped(t *testing.T) {
+	allg := NewAlligerDNA(-10, -1)
+	a := "TTTTTTACGTACGTACTTTTTT"
+	b := "GGGGGGACGTACCTACGGGGGG"
+	res, err := ExtendUngapped(allg, a, b, 8, 8, 20)
+	require.NoError(t, err)
+	require.Equal(t, "ACGTACGTAC", res.ResA)
+	require.Equal(t, "ACGTACCTAC", res.ResB)
+	require.Equal(t, float64(9*5-4), res.Score)
+	require.Equal(t, []int{6, 16, 6, 16}, []int{res.BegA, res.EndA, res.BegB, res.EndB})
+
+	// mismatch drops score by 4, more than x
+	res, err = ExtendUngapped(allg, a, b, 8, 8, 3)
+	require.NoError(t, err)
+	require.Equal(t, "ACGTAC", res.ResA)
+	require.Equal(t, float64(30), res.Score)
+
+	res, err = ExtendUngapped(allg, "A", "C", 0, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, "A", res.ResA)
+	require.Equal(t, float64(-4), res.Score)
+}
+
+// bestExtension is the best score of global allignment of prefixes of a and b
+func bestExtension(t *testing.T, allg Alligner, a, b string) float64 {
+	best := float64(0)
+	for p := 0; p <= len(a); p++ {
+		for q := 0; q <= len(b); q++ {
+			if p == 0 && q == 0 {
+				continue
+			}
+			score, err := Score(allg, a[:p], b[:q], Options{Threads: 1})
+			require.NoError(t, err)
+			best = math.Max(best, score)
+		}
+	}
+	return best
+}
+
+func TestExtendGapped(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for _, allg := range []Alligner{NewAlligerDNA(-6, -6), NewAlligerDNA(-10, -1), NewAlligerBLOSUM62(-11, -1)} {
+		alphabet := "ACGT"
+		if allg.InAlphabet('W') {
+			alphabet = "ARNDCQEGHILKMFPSTWYV"
+		}
+		for n := 0; n < 30; n++ {
+			a := randomSeq(r, alphabet, 1+r.Intn(12))
+			b := mutate(r, alphabet, a, 0.3)
+			if b == "" {
+				b = a
+			}
+			i, j := r.Intn(len(a)), r.Intn(len(b))
+			res, err := ExtendGapped(allg, a, b, i, j, math.Inf(1))
+			require.NoError(t, err)
+			require.Equal(t, res.Score, checkScoreOpts(allg, res.ResA, res.ResB, Options{}))
+			require.Equal(t, a[res.BegA:res.EndA], removeGaps(allg, res.ResA))
+			require.Equal(t, b[res.BegB:res.EndB], removeGaps(allg, res.ResB))
+
+			seed := allg.Compare(a[i], b[j])
+			left := bestExtension(t, allg, reverse(a[:i]), reverse(b[:j]))
+			right := bestExtension(t, allg, a[i+1:], b[j+1:])
+			require.Equal(t, left+seed+right, res.Score)
+
+			for _, x := range []float64{0, 5, 20} {
+				dropped, err := ExtendGapped(allg, a, b, i, j, x)
+				require.NoError(t, err)
+				require.LessOrEqual(t, dropped.Score, res.Score)
+				require.Equal(t, dropped.Score, checkScoreOpts(allg, dropped.ResA, dropped.ResB, Options{}))
+			}
+		}
+	}
+
+	allg := NewAlligerDNA(-10, -1)
+	res, err := ExtendGapped(allg, "TTACGTACGTTGCATGCA", "GGACGTAACGTTGCATGCA", 3, 3, 30)
+	require.NoError(t, err)
+	require.Equal(t, "ACGTA-CGTTGCATGCA", res.ResA)
+	require.Equal(t, "ACGTAACGTTGCATGCA", res.ResB)
+	require.Equal(t, []int{2, 18, 2, 19}, []int{res.BegA, res.EndA, res.BegB, res.EndB})
+
+	// gap is too expensive for small x
+	res, err = ExtendGapped(allg, "TTACGTACGTTGCATGCA", "GGACGTAACGTTGCATGCA", 3, 3, 5)
+	require.NoError(t, err)
+	require.Equal(t, "ACGTA", res.ResA)
+
+	_, err = ExtendGapped(allg, "ACGT", "ACGT", 4, 0, 10)
+	require.Error(t, err)
+	_, err = ExtendUngapped(allg, "ACGT", "ACGT", 0, 0, -1)
+	require.Error(t, err)
+
+	ext, err := NewExtender(allg, "TTACGTACGTTGCATGCA")
+	require.NoError(t, err)
+	require.NoError(t, ext.Check("GGACGTAACGTTGCATGCA"))
+	require.Equal(t, ErrBadSeq, errors.Cause(ext.Check("GGAJ")))
+	exp, err := ExtendGapped(allg, "TTACGTACGTTGCATGCA", "GGACGTAACGTTGCATGCA", 3, 3, 30)
+	require.NoError(t, err)
+	require.Equal(t, exp, ext.Gapped("GGACGTAACGTTGCATGCA", 3, 3, 30))
+	exp, err = ExtendUngapped(allg, "TTACGTACGTTGCATGCA", "GGACGTAACGTTGCATGCA", 3, 3, 30)
+	require.NoError(t, err)
+	require.Equal(t, exp, ext.Ungapped("GGACGTAACGTTGCATGCA", 3, 3, 30))
+	_, err = NewExtender(allg, "AJ")
+	require.Equal(t, ErrBadSeq, errors.Cause(err))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"lab2/sequence"
+	"log"
+	"strings"
+	"time"
+)
+
+func runShuffle(files []string) {
+	if useQuality || maskWeight != 1 {
+		fatal("-quality and -mask-weight can not be used in shuffle mode")
+	}
+	kind, ok := shuffleKinds[shuffleKind]
+	if !ok {
+		fatal("unknown shuffle kind %q", shuffleKind)
+	}
+	if shuffles < 2 {
+		fatal("-shuffles must be at least 2")
+	}
+	seq1, seq2 := readSeqsFromFiles(files)
+	seq1, seq2 = seq1.Ungapped(), seq2.Ungapped()
+
+	allg := queryAlligner(newAlligner(), seq1)
+	opts := withGaps(newOptions(), seq1, nil)
+	t := time.Now()
+	res, err := sequence.AllignWithOptions(allg, seq1.Value, seq2.Value, opts)
+	if err != nil {
+		fatal("alligning %s", err.Error())
+	}
+	sig, err := sequence.ShuffleSignificance(allg, seq1.Value, seq2.Value, opts, sequence.ShuffleOptions{
+		N:       shuffles,
+		Kind:    kind,
+		Seed:    shuffleSeed,
+		Threads: amThreads,
+	})
+	if err != nil {
+		fatal("shuffling %s", err.Error())
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+
+	printOut(func(withColor bool) string {
+		bld := strings.Builder{}
+		bld.WriteString(formatRes(allg, res, seq1, seq2, withColor))
+		bld.WriteString(fmt.Sprintf("shuffles: %d (%s) of seq2\n", shuffles, kind))
+		bld.WriteString(fmt.Sprintf("shuffled scores: mean %.4g, sd %.4g\n", sig.Mean, sig.SD))
+		bld.WriteString(fmt.Sprintf("Z-score: %.2f\n", sig.Z))
+		bld.WriteString(fmt.Sprintf("EVD: mu %.4g, lambda %.4g\n", sig.Gumbel.Mu, sig.Gumbel.Lambda))
+		bld.WriteString(fmt.Sprintf("p-value: %.3g\n", sig.PValue))
+		bld.WriteString(fmt.Sprintf("empirical p-value: %.3g\n", sig.EmpiricalPValue))
+		return bld.String()
+	})
+}
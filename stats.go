@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"lab2/sequence"
+	"log"
+)
+
+var (
+	// karlin are parameters of bit scores and E-values, nil if they are not reported
+	karlin *sequence.KarlinParams
+	// dbLength is amount of residues of database for E-values
+	dbLength int
+)
+
+// background returns residue frequencies of random sequences for selected table
+func background() sequence.Background {
+	if isNucleotideType() {
+		return sequence.UniformBackground("ACGT")
+	}
+	return sequence.RobinsonFrequencies
+}
+
+// initStats sets parameters of bit scores and E-values of local allignments,
+// n is amount of residues of database if -db-size is not set.
+// Pre-fitted parameters of built-in matrices are used if there are ones for the gap penalties,
+// otherwise they are estimated by allignments of random sequences if -estimate-stats is set.
+// Why there are no E-values is logged only if they are asked for by -db-size or -estimate-stats, or with -log-time.
+func initStats(n int) {
+	dbLength = n
+	if dbSize > 0 {
+		dbLength = dbSize
+	}
+	explain := isFlagPassed("db-size") || isFlagPassed("estimate-stats") || logTime
+	if !local {
+		if explain {
+			log.Print("no E-values: they are reported only for local allignments (-local)")
+		}
+		return
+	}
+	if matrixFile == "" {
+		name := tableType
+		if name == useBlosumOld {
+			name = useBlosum
+		}
+		if p, ok := sequence.GappedParams(name, gap, gapExt); ok {
+			karlin = &p
+			return
+		}
+	}
+	if !estStats {
+		if explain {
+			log.Print("no E-values: no pre-fitted parameters for the matrix and gap penalties, " +
+				"-estimate-stats estimates them by allignments of random sequences")
+		}
+		return
+	}
+	p, err := sequence.EstimateParams(newAlligner(), background(), sequence.DefaultEstimateOptions)
+	if err != nil {
+		log.Printf("no E-values: %s", err)
+		return
+	}
+	log.Printf("estimated lambda %.4g and K %.4g for E-values", p.Lambda, p.K)
+	karlin = &p
+}
+
+// formatStats returns bit score and E-value of score of query of length m, empty if they are not reported
+func formatStats(score float64, m int) string {
+	if karlin == nil {
+		return ""
+	}
+	return fmt.Sprintf("bit score: %.1f\nE-value: %.3g\n", karlin.BitScore(score), karlin.EValue(score, m, dbLength))
+}
+
+// statsColumns returns tab separated bit score and E-value for hit lists, empty if they are not reported
+func statsColumns(score float64, m int) string {
+	if karlin == nil {
+		return ""
+	}
+	return fmt.Sprintf("\t%.1f\t%.3g", karlin.BitScore(score), karlin.EValue(score, m, dbLength))
+}
@@ -0,0 +1,70 @@
+package tree
+
+import "math"
+
+// NJ builds tree by Saitou-Nei neighbor joining.
+// The tree is unrooted, it is returned rooted at the node joining the last three clusters.
+// Negative branch lengths are set to 0.
+func NJ(names []string, dist [][]float64) (*Node, error) {
+	if err := checkMatrix(names, dist); err != nil {
+		return nil, err
+	}
+	d := copyMatrix(dist)
+	nodes := leaves(names)
+	active := make([]int, len(nodes))
+	for i := range active {
+		active[i] = i
+	}
+	if len(active) == 1 {
+		return nodes[0], nil
+	}
+
+	for len(active) > 3 {
+		n := float64(len(active))
+		sums := make(map[int]float64, len(active))
+		for _, i := range active {
+			for _, k := range active {
+				sums[i] += d[i][k]
+			}
+		}
+		bi, bj := 0, 1
+		best := math.Inf(1)
+		for x := 0; x < len(active); x++ {
+			for y := x + 1; y < len(active); y++ {
+				i, j := active[x], active[y]
+				q := (n-2)*d[i][j] - sums[i] - sums[j]
+				if q < best {
+					best, bi, bj = q, x, y
+				}
+			}
+		}
+		i, j := active[bi], active[bj]
+		li := d[i][j]/2 + (sums[i]-sums[j])/(2*(n-2))
+		nodes[i].Length = math.Max(li, 0)
+		nodes[j].Length = math.Max(d[i][j]-li, 0)
+		parent := &Node{Leaf: -1, Children: []*Node{nodes[i], nodes[j]}}
+		for _, k := range active {
+			if k == i || k == j {
+				continue
+			}
+			v := (d[i][k] + d[j][k] - d[i][j]) / 2
+			d[i][k], d[k][i] = v, v
+		}
+		nodes[i] = parent
+		active = append(active[:bj], active[bj+1:]...)
+	}
+
+	root := &Node{Leaf: -1}
+	if len(active) == 2 {
+		i, j := active[0], active[1]
+		nodes[i].Length, nodes[j].Length = d[i][j]/2, d[i][j]/2
+		root.Children = []*Node{nodes[i], nodes[j]}
+		return root, nil
+	}
+	i, j, k := active[0], active[1], active[2]
+	nodes[i].Length = math.Max((d[i][j]+d[i][k]-d[j][k])/2, 0)
+	nodes[j].Length = math.Max((d[i][j]+d[j][k]-d[i][k])/2, 0)
+	nodes[k].Length = math.Max((d[i][k]+d[j][k]-d[i][j])/2, 0)
+	root.Children = []*Node{nodes[i], nodes[j], nodes[k]}
+	return root, nil
+}
@@ -0,0 +1,150 @@
+// Package tree builds guide and phylogenetic trees from distance matrices
+package tree
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBadMatrix is returned when distance matrix is not square, symmetric or has bad values
+var ErrBadMatrix = errors.New("bad distance matrix")
+
+// Node is a node of a tree, leaves correspond to rows of distance matrix
+type Node struct {
+	Name string
+	// Leaf is the index of leaf in distance matrix, -1 for inner nodes
+	Leaf     int
+	Children []*Node
+	// Length is the length of branch to parent
+	Length float64
+}
+
+// IsLeaf reports if node is a leaf
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Leaves returns matrix indices of leaves in the order of tree traversal
+func (n *Node) Leaves() []int {
+	if n.IsLeaf() {
+		return []int{n.Leaf}
+	}
+	var res []int
+	for _, c := range n.Children {
+		res = append(res, c.Leaves()...)
+	}
+	return res
+}
+
+// PostOrder calls fn for children before their parent
+func (n *Node) PostOrder(fn func(n *Node)) {
+	for _, c := range n.Children {
+		c.PostOrder(fn)
+	}
+	fn(n)
+}
+
+// Newick returns the tree in Newick format with branch lengths
+func (n *Node) Newick() string {
+	bld := &strings.Builder{}
+	n.writeNewick(bld, true)
+	bld.WriteByte(';')
+	return bld.String()
+}
+
+func (n *Node) writeNewick(bld *strings.Builder, root bool) {
+	if !n.IsLeaf() {
+		bld.WriteByte('(')
+		for i, c := range n.Children {
+			if i > 0 {
+				bld.WriteByte(',')
+			}
+			c.writeNewick(bld, false)
+		}
+		bld.WriteByte(')')
+	}
+	bld.WriteString(newickName(n.Name))
+	if !root {
+		bld.WriteString(fmt.Sprintf(":%.6g", n.Length))
+	}
+}
+
+// newickName quotes name if it has symbols with special meaning in Newick
+func newickName(name string) string {
+	if !strings.ContainsAny(name, " \t()[]':;,") {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// SaturatedDistance is the cap of distances of saturated pairs, the same value is used by ClustalW for Kimura distance
+const SaturatedDistance = 10.0
+
+// CapSaturated returns copy of dist with distances above limit (e.g. +Inf of Poisson and Kimura distances
+// of saturated pairs) set to limit and pairs i < j which were capped
+func CapSaturated(dist [][]float64, limit float64) ([][]float64, [][2]int) {
+	res := make([][]float64, len(dist))
+	var capped [][2]int
+	for i := range dist {
+		res[i] = append([]float64(nil), dist[i]...)
+		for j, d := range res[i] {
+			if d > limit {
+				res[i][j] = limit
+				if i < j {
+					capped = append(capped, [2]int{i, j})
+				}
+			}
+		}
+	}
+	return res, capped
+}
+
+func checkMatrix(names []string, dist [][]float64) error {
+	if len(names) == 0 {
+		return errors.Wrap(ErrBadMatrix, "no leaves")
+	}
+	if len(dist) != len(names) {
+		return errors.Wrapf(ErrBadMatrix, "%d names for %d rows", len(names), len(dist))
+	}
+	for i := range dist {
+		if len(dist[i]) != len(dist) {
+			return errors.Wrapf(ErrBadMatrix, "row %d has %d values, expected %d", i, len(dist[i]), len(dist))
+		}
+	}
+	for i := range dist {
+		for j := range dist[i] {
+			d := dist[i][j]
+			switch {
+			case math.IsInf(d, 1):
+				return errors.Wrapf(ErrBadMatrix, "distance %s-%s is saturated, cap it by CapSaturated or use p-distance", names[i], names[j])
+			case math.IsNaN(d) || math.IsInf(d, 0):
+				return errors.Wrapf(ErrBadMatrix, "distance %d-%d is %g", i, j, d)
+			case d != dist[j][i]:
+				return errors.Wrapf(ErrBadMatrix, "not symmetric at %d-%d", i, j)
+			case d < 0:
+				return errors.Wrapf(ErrBadMatrix, "negative distance %d-%d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func leaves(names []string) []*Node {
+	nodes := make([]*Node, len(names))
+	for i, name := range names {
+		nodes[i] = &Node{Name: name, Leaf: i}
+	}
+	return nodes
+}
+
+// copyMatrix copies dist so it can be reduced during clustering
+func copyMatrix(dist [][]float64) [][]float64 {
+	res := make([][]float64, len(dist))
+	for i := range dist {
+		res[i] = append([]float64(nil), dist[i]...)
+	}
+	return res
+}
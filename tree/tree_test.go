@@ -0,0 +1,157 @@
+package tree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// leafDistances returns lengths of paths between leaves of the tree
+func leafDistances(root *Node, n int) [][]float64 {
+	res := make([][]float64, n)
+	for i := range res {
+		res[i] = make([]float64, n)
+	}
+	// depths of leaves under every node
+	var walk func(node *Node) map[int]float64
+	walk = func(node *Node) map[int]float64 {
+		if node.IsLeaf() {
+			return map[int]float64{node.Leaf: 0}
+		}
+		var subtrees []map[int]float64
+		for _, c := range node.Children {
+			depths := walk(c)
+			for l := range depths {
+				depths[l] += c.Length
+			}
+			subtrees = append(subtrees, depths)
+		}
+		all := map[int]float64{}
+		for x, a := range subtrees {
+			for y, b := range subtrees {
+				if x == y {
+					continue
+				}
+				for i, di := range a {
+					for j, dj := range b {
+						res[i][j] = di + dj
+					}
+				}
+			}
+			for l, d := range a {
+				all[l] = d
+			}
+		}
+		return all
+	}
+	walk(root)
+	return res
+}
+
+func requireMatrixInDelta(t *testing.T, expected, actual [][]float64) {
+	for i := range expected {
+		for j := range expected[i] {
+			require.InDelta(t, expected[i][j], actual[i][j], 1e-9, "%d %d", i, j)
+		}
+	}
+}
+
+func TestNJAdditive(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	dist := [][]float64{
+		{0, 5, 9, 9, 8},
+		{5, 0, 10, 10, 9},
+		{9, 10, 0, 8, 7},
+		{9, 10, 8, 0, 3},
+		{8, 9, 7, 3, 0},
+	}
+	root, err := NJ(names, dist)
+	require.NoError(t, err)
+	requireMatrixInDelta(t, dist, leafDistances(root, len(names)))
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4}, root.Leaves())
+
+	root, err = NJ(names[:2], [][]float64{{0, 4}, {4, 0}})
+	require.NoError(t, err)
+	require.Equal(t, "(a:2,b:2);", root.Newick())
+}
+
+func TestUPGMAUltrametric(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+	dist := [][]float64{
+		{0, 2, 6, 10},
+		{2, 0, 6, 10},
+		{6, 6, 0, 10},
+		{10, 10, 10, 0},
+	}
+	root, err := UPGMA(names, dist)
+	require.NoError(t, err)
+	requireMatrixInDelta(t, dist, leafDistances(root, len(names)))
+	require.Equal(t, "(((a:1,b:1):2,c:3):2,d:5);", root.Newick())
+}
+
+func TestUPGMAAverage(t *testing.T) {
+	root, err := UPGMA([]string{"a", "b", "c"}, [][]float64{
+		{0, 2, 4},
+		{2, 0, 8},
+		{4, 8, 0},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "((a:1,b:1):2,c:3);", root.Newick())
+}
+
+func TestNewickNames(t *testing.T) {
+	root, err := UPGMA([]string{"seq one", "it's"}, [][]float64{{0, 1}, {1, 0}})
+	require.NoError(t, err)
+	require.Equal(t, "('seq one':0.5,'it''s':0.5);", root.Newick())
+
+	root, err = NJ([]string{"x"}, [][]float64{{0}})
+	require.NoError(t, err)
+	require.Equal(t, "x;", root.Newick())
+}
+
+func TestBadMatrix(t *testing.T) {
+	tcs := []struct {
+		names []string
+		dist  [][]float64
+	}{
+		{nil, nil},
+		{[]string{"a"}, [][]float64{{0}, {1}}},
+		{[]string{"a", "b"}, [][]float64{{0, 1}, {2, 0}}},
+		{[]string{"a", "b"}, [][]float64{{0, -1}, {-1, 0}}},
+		{[]string{"a", "b"}, [][]float64{{0, 1}, {1}}},
+		{[]string{"a", "b"}, [][]float64{{0}, {1, 0}}},
+		{[]string{"a", "b"}, [][]float64{{0, math.Inf(1)}, {math.Inf(1), 0}}},
+	}
+	for i, tc := range tcs {
+		_, err := UPGMA(tc.names, tc.dist)
+		require.Equal(t, ErrBadMatrix, errors.Cause(err), "test %d", i)
+		_, err = NJ(tc.names, tc.dist)
+		require.Equal(t, ErrBadMatrix, errors.Cause(err), "test %d", i)
+	}
+}
+
+func TestCapSaturated(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	inf := math.Inf(1)
+	dist := [][]float64{
+		{0, 0.2, inf},
+		{0.2, 0, 12},
+		{inf, 12, 0},
+	}
+	_, err := UPGMA(names, dist)
+	require.Equal(t, ErrBadMatrix, errors.Cause(err))
+	require.Contains(t, err.Error(), "a-c is saturated")
+
+	capped, pairs := CapSaturated(dist, SaturatedDistance)
+	require.Equal(t, [][2]int{{0, 2}, {1, 2}}, pairs)
+	require.Equal(t, [][]float64{{0, 0.2, 10}, {0.2, 0, 10}, {10, 10, 0}}, capped)
+	require.True(t, math.IsInf(dist[0][2], 1))
+
+	root, err := UPGMA(names, capped)
+	require.NoError(t, err)
+	require.Equal(t, "((a:0.1,b:0.1):4.9,c:5);", root.Newick())
+	_, err = NJ(names, capped)
+	require.NoError(t, err)
+}
@@ -0,0 +1,45 @@
+package tree
+
+// UPGMA builds rooted ultrametric tree by average linkage clustering.
+// Ties are resolved in favour of the pair with the smallest indices.
+func UPGMA(names []string, dist [][]float64) (*Node, error) {
+	if err := checkMatrix(names, dist); err != nil {
+		return nil, err
+	}
+	d := copyMatrix(dist)
+	nodes := leaves(names)
+	sizes := make([]int, len(nodes))
+	heights := make([]float64, len(nodes))
+	active := make([]int, len(nodes))
+	for i := range nodes {
+		sizes[i] = 1
+		active[i] = i
+	}
+
+	for len(active) > 1 {
+		bi, bj := 0, 1
+		for x := 0; x < len(active); x++ {
+			for y := x + 1; y < len(active); y++ {
+				if d[active[x]][active[y]] < d[active[bi]][active[bj]] {
+					bi, bj = x, y
+				}
+			}
+		}
+		i, j := active[bi], active[bj]
+		h := d[i][j] / 2
+		nodes[i].Length = h - heights[i]
+		nodes[j].Length = h - heights[j]
+		parent := &Node{Leaf: -1, Children: []*Node{nodes[i], nodes[j]}}
+		for _, k := range active {
+			if k == i || k == j {
+				continue
+			}
+			v := (d[i][k]*float64(sizes[i]) + d[j][k]*float64(sizes[j])) / float64(sizes[i]+sizes[j])
+			d[i][k], d[k][i] = v, v
+		}
+		// cluster replaces i, j is removed
+		nodes[i], sizes[i], heights[i] = parent, sizes[i]+sizes[j], h
+		active = append(active[:bj], active[bj+1:]...)
+	}
+	return nodes[active[0]], nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"lab2/tree"
+	"log"
+	"time"
+)
+
+const (
+	treeUPGMA = "upgma"
+	treeNJ    = "nj"
+)
+
+// buildTree builds tree of kind from distance matrix
+func buildTree(kind string, names []string, dist [][]float64) (*tree.Node, error) {
+	if kind == treeNJ {
+		return tree.NJ(names, dist)
+	}
+	return tree.UPGMA(names, dist)
+}
+
+func runTree(files []string) {
+	if treeKind != treeUPGMA && treeKind != treeNJ {
+		fatal("unknown tree method %q", treeKind)
+	}
+	kind := matKimura
+	if isFlagPassed("out-matrix") {
+		kind = outMatrix
+	}
+	switch kind {
+	case matP, matPoisson, matKimura:
+	default:
+		fatal("tree needs a distance matrix: p, poisson or kimura, got %q", kind)
+	}
+	seqs := readAllSeqs(files)
+	if len(seqs) < 2 {
+		fatal("bad amount of sequeces %d", len(seqs))
+	}
+
+	t := time.Now()
+	pm := allVsAll(seqs, true)
+	names := seqNames(seqs)
+	dist, saturated := tree.CapSaturated(distanceMatrix(pm, kind), tree.SaturatedDistance)
+	if len(saturated) > 0 {
+		p := saturated[0]
+		log.Printf("%d saturated pairs (e.g. %s and %s), their distance is capped at %g, -out-matrix p has no saturation",
+			len(saturated), names[p[0]], names[p[1]], tree.SaturatedDistance)
+	}
+	root, err := buildTree(treeKind, names, dist)
+	if err != nil {
+		fatal("building tree: %s", err)
+	}
+	if logTime {
+		log.Print("calculation time: ", time.Now().Sub(t))
+	}
+	printOut(func(bool) string {
+		return root.Newick() + "\n"
+	})
+}
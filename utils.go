@@ -6,9 +6,22 @@ import (
 )
 
 const (
-	useBlosum  = "Blosum64"
+	useBlosum  = "Blosum62"
 	useDNA     = "DNA"
 	useDefault = "Default"
+	// useBlosumOld is the old mislabeled name of Blosum62, kept for compatibility
+	useBlosumOld = "Blosum64"
+
+	modePair = "pair"
+	modeDB   = "db"
+	modeAll  = "all"
+	modeTree = "tree"
+	modeMSA  = "msa"
+
+	modeIndex  = "index"
+	modeSearch = "search"
+
+	modeShuffle = "shuffle"
 )
 
 var (
@@ -18,10 +31,43 @@ var (
 	outFile      string
 	noColor      bool
 	memOpt       bool
+	local        bool
+	freeEnds     string
+	matrixFile   string
+	pssmFile     string
+	gapsFile1    string
+	gapsFile2    string
+	kBest        int
+	tieBreak     string
+	countOptimal bool
+	enumOptimal  int
+	bandWidth    int
+	wordSize     int
+	seedMask     string
+	neighbor     float64
+	xDrop        float64
+	xDropGapped  float64
+	minUngapped  float64
+	dbSize       int
+	estStats     bool
+	shuffles     int
+	shuffleKind  string
+	shuffleSeed  int64
 	noConnectios bool
 	logTime      bool
 	amThreads    int
 	outAlignment uint
+	dnaMatch     float64
+	transition   float64
+	transversion float64
+	maskWeight   float64
+	headerStyle  string
+	useQuality   bool
+	runMode      string
+	top          int
+	outMatrix    string
+	outFormat    string
+	treeKind     string
 )
 
 func fatal(format string, v ...interface{}) {